@@ -0,0 +1,63 @@
+package ebo
+
+// Result holds the outcome of a retried operation that produces a value.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// RetryAsync runs Retry in a new goroutine and delivers its outcome on the
+// returned channel. Exactly one value is sent, after which the channel is closed.
+// Use WithContext to cancel the operation.
+//
+// Example:
+//
+//	errCh := ebo.RetryAsync(func() error {
+//	    return syncInventory()
+//	}, ebo.WithContext(ctx), ebo.API())
+//
+//	// ... do other work ...
+//
+//	if err := <-errCh; err != nil {
+//	    log.Printf("sync failed: %v", err)
+//	}
+func RetryAsync(fn RetryableFunc, opts ...Option) <-chan error {
+	ch := make(chan error, 1)
+
+	go func() {
+		defer close(ch)
+		ch <- Retry(fn, opts...)
+	}()
+
+	return ch
+}
+
+// RetryValueAsync runs RetryValue in a new goroutine and delivers its value
+// and error on the returned channel. Exactly one Result is sent, after which
+// the channel is closed. Use WithContext to cancel the operation.
+//
+// Example:
+//
+//	resultCh := ebo.RetryValueAsync(func() (*User, error) {
+//	    return fetchUser(id)
+//	}, ebo.WithContext(ctx), ebo.Quick())
+//
+//	res := <-resultCh
+//	if res.Err != nil {
+//	    return res.Err
+//	}
+//	fmt.Println(res.Value.Name)
+func RetryValueAsync[T any](fn func() (T, error), opts ...Option) <-chan Result[T] {
+	ch := make(chan Result[T], 1)
+	config := newConfig(opts...)
+	valid := validator[T](config) // Panics here rather than in the goroutine
+
+	go func() {
+		defer close(ch)
+
+		value, err := retryValue(config, valid, fn)
+		ch <- Result[T]{Value: value, Err: err}
+	}()
+
+	return ch
+}
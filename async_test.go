@@ -0,0 +1,114 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAsync(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		attempts := int32(0)
+		errCh := RetryAsync(func() error {
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				return errors.New("temporary error")
+			}
+			return nil
+		}, Initial(10*time.Millisecond))
+
+		if err := <-errCh; err != nil {
+			t.Errorf("expected success, got error: %v", err)
+		}
+		if _, ok := <-errCh; ok {
+			t.Error("expected channel to be closed after result")
+		}
+		if atomic.LoadInt32(&attempts) != 3 {
+			t.Errorf("expected 3 attempts, got %d", atomic.LoadInt32(&attempts))
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		expectedErr := errors.New("always fail")
+		errCh := RetryAsync(func() error {
+			return expectedErr
+		}, Initial(10*time.Millisecond), Tries(2))
+
+		if err := <-errCh; !errors.Is(err, expectedErr) {
+			t.Errorf("expected %v, got %v", expectedErr, err)
+		}
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		errCh := RetryAsync(func() error {
+			return errors.New("always fail")
+		}, WithContext(ctx), Initial(time.Second), Tries(10))
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("expected async retry to stop after cancellation")
+		}
+	})
+}
+
+func TestRetryValueAsync(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		attempts := int32(0)
+		resultCh := RetryValueAsync(func() (string, error) {
+			if atomic.AddInt32(&attempts, 1) < 2 {
+				return "", errors.New("temporary error")
+			}
+			return "done", nil
+		}, Initial(10*time.Millisecond))
+
+		res := <-resultCh
+		if res.Err != nil {
+			t.Errorf("expected success, got error: %v", res.Err)
+		}
+		if res.Value != "done" {
+			t.Errorf("expected value 'done', got %q", res.Value)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		resultCh := RetryValueAsync(func() (int, error) {
+			return 42, errors.New("always fail")
+		}, Initial(10*time.Millisecond), Tries(2))
+
+		res := <-resultCh
+		if res.Err == nil {
+			t.Error("expected error, got nil")
+		}
+		if res.Value != 0 {
+			t.Errorf("expected zero value, got %d", res.Value)
+		}
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		attempts := int32(0)
+		resultCh := RetryValueAsync(func() (int, error) {
+			atomic.AddInt32(&attempts, 1)
+			return 1, nil
+		}, WithContext(ctx))
+
+		res := <-resultCh
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", res.Err)
+		}
+		if atomic.LoadInt32(&attempts) != 0 {
+			t.Errorf("expected no attempts, got %d", atomic.LoadInt32(&attempts))
+		}
+	})
+}
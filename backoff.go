@@ -0,0 +1,189 @@
+package ebo
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// The delay before attempt N is defined as follows:
+//
+//   - with NoDelay, every attempt runs immediately and none of the below applies
+//   - attempt 1 waits exactly FirstDelay, without jitter (0 by default),
+//     and attempt 2 runs immediately with ImmediateFirstRetry
+//   - otherwise the base delay is Initial * Multiplier^(N-2), clamped to
+//     [Min, Max]; a Multiplier <= 0 is treated as 1, and an Initial <= 0
+//     as 1ms so that retries never busy-loop
+//   - with WarmUpTries(n, d), attempts 2 to n+1 wait d instead, and the
+//     schedule then starts over: attempt N > n+1 waits as attempt N-n would
+//   - with Multipliers(f0, f1, ...), Multiplier^(N-2) is replaced by the
+//     product of the first N-2 factors, repeating the last one as needed
+//   - with a jitter factor f > 0, the delay is drawn uniformly from
+//     [delay*(1-f), min(delay*(1+f), Max)], so Max is never exceeded
+//   - with MaxJitter(m), that range is further narrowed to
+//     [delay-m, delay+m], so jitter never moves the delay by more than m
+//   - with JitterCap(g), Max itself is drawn once per retry loop from
+//     [Max*(1-g), Max*(1+g)] before any of the above applies
+//
+// The base delay is computed from the attempt number rather than by growing
+// the previous delay, so it does not drift from Initial * Multiplier^(N-2)
+// over long Forever loops, and growth past Max is clamped to Max.
+//
+// Retry, the iterators and NextInterval all use this calculation, except that
+// NextInterval, which does not know the attempt number, ignores Multipliers.
+
+// delay returns the (jittered) delay before the given 1-based attempt.
+func (c *RetryConfig) delay(attempt int) time.Duration {
+	delay, _ := c.jitteredDelay(attempt)
+	return delay
+}
+
+// jitteredDelay returns the delay before the given 1-based attempt and the
+// signed offset that jitter added to its base delay.
+func (c *RetryConfig) jitteredDelay(attempt int) (delay, jitter time.Duration) {
+	if c.noDelay {
+		return 0, 0
+	}
+	if attempt == 1 {
+		return c.firstDelay, 0
+	}
+	if c.immediate(attempt - 1) {
+		return 0, 0
+	}
+	if c.warmUpTries > 0 {
+		if attempt <= c.warmUpTries+1 {
+			delay = c.jitter(c.warmUpInterval)
+			return delay, delay - c.warmUpInterval
+		}
+		attempt -= c.warmUpTries
+	}
+	base := c.backoff(attempt)
+	delay = c.jitter(base)
+	return delay, delay - base
+}
+
+// backoff returns the base delay before the given 1-based attempt, without jitter.
+func (c *RetryConfig) backoff(attempt int) time.Duration {
+	return c.grow(c.InitialInterval, c.growth(attempt-2))
+}
+
+// grow returns interval multiplied by factor and clamped to [Min, Max]. A
+// non-positive interval counts as minEffectiveInterval, so that retries never
+// busy-loop. backoff and NextInterval both rely on it.
+func (c *RetryConfig) grow(interval time.Duration, factor float64) time.Duration {
+	if interval <= 0 {
+		interval = minEffectiveInterval
+	}
+	return c.clamp(float64(interval) * factor)
+}
+
+// growth returns the factor by which the interval has grown after n steps:
+// Multiplier^n, or the product of the first n Multipliers factors with the
+// last one repeated once they run out.
+func (c *RetryConfig) growth(n int) float64 {
+	if len(c.multipliers) == 0 {
+		return math.Pow(c.multiplier(), float64(n))
+	}
+
+	factor := 1.0
+	for _, m := range c.multipliers[:min(n, len(c.multipliers))] {
+		factor *= positive(m)
+	}
+	if rest := n - len(c.multipliers); rest > 0 {
+		factor *= math.Pow(positive(c.multipliers[len(c.multipliers)-1]), float64(rest))
+	}
+	return factor
+}
+
+// positive returns m, treating non-positive values as 1.
+func positive(m float64) float64 {
+	if m <= 0 {
+		return 1
+	}
+	return m
+}
+
+// multiplier returns the growth factor, treating non-positive values as 1.
+func (c *RetryConfig) multiplier() float64 {
+	return positive(c.Multiplier)
+}
+
+// clamp limits d to [MinInterval, MaxInterval].
+func (c *RetryConfig) clamp(d float64) time.Duration {
+	if d >= float64(c.MaxInterval) {
+		return c.MaxInterval
+	}
+	if d <= float64(c.MinInterval) {
+		return c.MinInterval
+	}
+	return time.Duration(d)
+}
+
+// jitter randomizes d within [d*(1-f), min(d*(1+f), MaxInterval)], narrowed
+// to [d-m, d+m] for m = maxJitter.
+func (c *RetryConfig) jitter(d time.Duration) time.Duration {
+	f := c.RandomizeFactor
+	if f <= 0 {
+		return d
+	}
+
+	lo := float64(d) * (1 - f)
+	hi := min(float64(d)*(1+f), float64(c.MaxInterval))
+	if m := float64(c.maxJitter); m > 0 {
+		lo = max(lo, float64(d)-m)
+		hi = min(hi, float64(d)+m)
+	}
+	if hi <= lo {
+		return time.Duration(lo)
+	}
+
+	return time.Duration(lo + c.float64()*(hi-lo))
+}
+
+// jitterMax returns MaxInterval randomized within [Max*(1-g), Max*(1+g)] for g = capJitter.
+func (c *RetryConfig) jitterMax() time.Duration {
+	lo := float64(c.MaxInterval) * (1 - c.capJitter)
+	hi := float64(c.MaxInterval) * (1 + c.capJitter)
+	return time.Duration(lo + c.float64()*(hi-lo))
+}
+
+// float64 returns a random number in [0, 1) from the configured source.
+func (c *RetryConfig) float64() float64 {
+	if c.random == nil {
+		return rand.Float64()
+	}
+	return c.random()
+}
+
+// seededRandom returns a source of numbers in [0, 1) seeded with seed.
+// It is safe for the concurrent use of a configuration shared by RetryEach.
+func seededRandom(seed int64) func() float64 {
+	var mu sync.Mutex
+	r := rand.New(rand.NewSource(seed))
+	return func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return r.Float64()
+	}
+}
+
+// NextInterval calculates the interval that follows current under cfg.
+// It applies the multiplier, clamps the result to [MinInterval, MaxInterval]
+// and then adds jitter according to RandomizeFactor, using the same math as
+// Retry and the iterators; like Initial there, a current <= 0 counts as 1ms.
+// current should be the previous interval before jitter, so that jitter does
+// not compound.
+// Useful when driving a custom retry loop with the library's backoff math.
+//
+// Example:
+//
+//	cfg := ebo.RetryConfig{InitialInterval: time.Second, MaxInterval: 30 * time.Second, Multiplier: 2.0}
+//	interval := cfg.InitialInterval
+//	for !done() {
+//	    time.Sleep(interval)
+//	    interval = ebo.NextInterval(interval, cfg)
+//	}
+func NextInterval(current time.Duration, cfg RetryConfig) time.Duration {
+	return cfg.jitter(cfg.grow(current, cfg.multiplier()))
+}
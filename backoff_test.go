@@ -0,0 +1,554 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+// withRandom makes jitter deterministic for tests
+func withRandom(seed int64) Option {
+	return func(c *RetryConfig) {
+		c.random = rand.New(rand.NewSource(seed)).Float64
+	}
+}
+
+func TestDelayContract(t *testing.T) {
+	opts := []Option{
+		Initial(time.Millisecond),
+		Max(10 * time.Millisecond),
+		Multiplier(2.0),
+		Jitter(0.5),
+		Tries(8),
+	}
+
+	t.Run("base delays", func(t *testing.T) {
+		config := newConfig(opts...)
+		want := []time.Duration{
+			0, // attempt 1 is never delayed
+			1 * time.Millisecond,
+			2 * time.Millisecond,
+			4 * time.Millisecond,
+			8 * time.Millisecond,
+			10 * time.Millisecond, // clamped by Max
+			10 * time.Millisecond,
+			10 * time.Millisecond,
+		}
+
+		for n := 2; n <= 8; n++ {
+			if got := config.backoff(n); got != want[n-1] {
+				t.Errorf("attempt %d: base delay = %v, want %v", n, got, want[n-1])
+			}
+		}
+	})
+
+	t.Run("golden jittered sequence", func(t *testing.T) {
+		config := newConfig(append(opts, withRandom(42))...)
+		got := make([]time.Duration, 0, 8)
+		for n := 1; n <= 8; n++ {
+			got = append(got, config.delay(n))
+		}
+
+		assertGolden(t, got)
+	})
+
+	t.Run("iterator follows the contract", func(t *testing.T) {
+		got := make([]time.Duration, 0, 8)
+		for attempt := range Attempts(append(opts, withRandom(42))...) {
+			got = append(got, attempt.Delay)
+		}
+
+		assertGolden(t, got)
+	})
+
+	t.Run("jitter never exceeds max", func(t *testing.T) {
+		config := newConfig(append(opts, withRandom(7))...)
+		for range 1000 {
+			if got := config.delay(8); got > config.MaxInterval || got < 5*time.Millisecond {
+				t.Fatalf("delay %v outside [5ms, 10ms]", got)
+			}
+		}
+	})
+
+	t.Run("min clamps base delay", func(t *testing.T) {
+		config := newConfig(Initial(time.Millisecond), Min(3*time.Millisecond), NoJitter())
+		if got := config.delay(2); got != 3*time.Millisecond {
+			t.Errorf("expected 3ms, got %v", got)
+		}
+	})
+}
+
+func TestNonPositiveInitial(t *testing.T) {
+	for _, initial := range []time.Duration{0, -time.Second} {
+		config := newConfig(Initial(initial), Linear(), NoJitter())
+		if got := config.delay(5); got != minEffectiveInterval {
+			t.Errorf("Initial(%v): expected %v delay, got %v", initial, minEffectiveInterval, got)
+		}
+	}
+
+	t.Run("NextInterval", func(t *testing.T) {
+		cfg := RetryConfig{InitialInterval: 0, MaxInterval: time.Second, Multiplier: 2}
+		for _, current := range []time.Duration{0, -time.Second} {
+			if got, want := NextInterval(current, cfg), 2*minEffectiveInterval; got != want {
+				t.Errorf("NextInterval(%v) = %v, want %v", current, got, want)
+			}
+		}
+	})
+
+	t.Run("retries are spaced", func(t *testing.T) {
+		attempts := 0
+		start := time.Now()
+		_ = Retry(func() error {
+			attempts++
+			return errors.New("always fail")
+		}, Initial(0), Linear(), NoJitter(), Tries(6))
+		elapsed := time.Since(start)
+
+		if attempts != 6 {
+			t.Errorf("expected 6 attempts, got %d", attempts)
+		}
+		if elapsed < 5*minEffectiveInterval {
+			t.Errorf("expected at least %v between 6 attempts, took %v", 5*minEffectiveInterval, elapsed)
+		}
+	})
+}
+
+func TestNoDelay(t *testing.T) {
+	attempts := 0
+	var delays []time.Duration
+	start := time.Now()
+	err := Retry(func() error {
+		attempts++
+		return RetryAfter(errors.New("conflict"), time.Second)
+	}, NoDelay(), Tries(5), FirstDelay(time.Second), OnRetry(func(_ int, delay, _ time.Duration) {
+		delays = append(delays, delay)
+	}))
+	elapsed := time.Since(start)
+
+	if err == nil || attempts != 5 {
+		t.Errorf("expected 5 failed attempts, got %d and %v", attempts, err)
+	}
+	// The busy-loop floor alone would take 4ms between 5 attempts
+	if elapsed >= 4*minEffectiveInterval {
+		t.Errorf("expected 5 attempts without sleeping, took %v", elapsed)
+	}
+	for i, delay := range delays {
+		if delay != 0 {
+			t.Errorf("retry %d: expected no delay, got %v", i+1, delay)
+		}
+	}
+}
+
+func TestNonPositiveMultiplier(t *testing.T) {
+	for _, multiplier := range []float64{0, -2} {
+		config := newConfig(Initial(2*time.Millisecond), Multiplier(multiplier), NoJitter())
+		for n := 2; n <= 5; n++ {
+			if got := config.delay(n); got != 2*time.Millisecond {
+				t.Errorf("Multiplier(%v): attempt %d delay = %v, want 2ms", multiplier, n, got)
+			}
+		}
+	}
+
+	t.Run("retries are spaced", func(t *testing.T) {
+		attempts := 0
+		start := time.Now()
+		_ = Retry(func() error {
+			attempts++
+			return errors.New("always fail")
+		}, Initial(2*time.Millisecond), Multiplier(0), NoJitter(), Tries(5))
+		elapsed := time.Since(start)
+
+		if attempts != 5 {
+			t.Errorf("expected 5 attempts, got %d", attempts)
+		}
+		if elapsed < 8*time.Millisecond {
+			t.Errorf("expected at least 8ms between 5 attempts, took %v", elapsed)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		for attempt := range Attempts(Initial(2*time.Millisecond), Multiplier(0), NoJitter(), Tries(4)) {
+			if attempt.Number > 1 && attempt.Delay != 2*time.Millisecond {
+				t.Errorf("attempt %d: delay = %v, want 2ms", attempt.Number, attempt.Delay)
+			}
+		}
+	})
+}
+
+func TestClosedFormBackoff(t *testing.T) {
+	const multiplier = 1.1
+	initial := 100 * time.Microsecond
+	closedForm := func(attempt int) time.Duration {
+		return time.Duration(float64(initial) * math.Pow(multiplier, float64(attempt-2)))
+	}
+
+	config := newConfig(Initial(initial), Max(time.Hour), Multiplier(multiplier), NoJitter())
+	iterative := initial
+	for attempt := 2; attempt <= 21; attempt++ {
+		want := closedForm(attempt)
+		got := config.delay(attempt)
+		if (got - want).Abs() > time.Nanosecond {
+			t.Errorf("attempt %d: delay = %v, want %v", attempt, got, want)
+		}
+
+		// Multiplying the previous interval truncates at every step and drifts
+		if (got - want).Abs() > (iterative - want).Abs() {
+			t.Errorf("attempt %d: delay %v is further from %v than the iterative %v", attempt, got, want, iterative)
+		}
+		iterative = NextInterval(iterative, *config)
+	}
+
+	t.Run("iterator", func(t *testing.T) {
+		for attempt := range Attempts(Initial(initial), Max(time.Hour), Multiplier(multiplier), NoJitter(), Tries(21)) {
+			if attempt.Number > 1 && attempt.Delay != closedForm(attempt.Number) {
+				t.Errorf("attempt %d: delay = %v, want %v", attempt.Number, attempt.Delay, closedForm(attempt.Number))
+			}
+		}
+	})
+
+	t.Run("overflow is capped", func(t *testing.T) {
+		config := newConfig(Initial(time.Second), Max(time.Minute), Multiplier(10), NoJitter())
+		for _, attempt := range []int{30, 400, 1 << 20} {
+			if got := config.delay(attempt); got != time.Minute {
+				t.Errorf("attempt %d: delay = %v, want the 1m cap", attempt, got)
+			}
+		}
+	})
+}
+
+func TestWarmUpTries(t *testing.T) {
+	opts := []Option{Initial(10 * time.Millisecond), Multiplier(2), Max(time.Second), NoJitter(), WarmUpTries(3, time.Millisecond)}
+	want := []time.Duration{
+		0,                // attempt 1
+		time.Millisecond, // attempts 2-4: warm-up
+		time.Millisecond,
+		time.Millisecond,
+		10 * time.Millisecond, // attempt 5: the normal schedule starts at Initial
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+	}
+
+	config := newConfig(opts...)
+	for i, w := range want {
+		if got := config.delay(i + 1); got != w {
+			t.Errorf("attempt %d: delay = %v, want %v", i+1, got, w)
+		}
+	}
+
+	t.Run("retry", func(t *testing.T) {
+		var delays []time.Duration
+		_ = Retry(func() error {
+			return errors.New("always fail")
+		}, append(opts, Tries(len(want)), OnRetry(func(_ int, delay, _ time.Duration) {
+			delays = append(delays, delay)
+		}))...)
+
+		if !slices.Equal(delays, want[1:]) {
+			t.Errorf("expected delays %v, got %v", want[1:], delays)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		var delays []time.Duration
+		for attempt := range Attempts(append(opts, Tries(len(want)))...) {
+			delays = append(delays, attempt.Delay)
+		}
+		if !slices.Equal(delays, want) {
+			t.Errorf("expected delays %v, got %v", want, delays)
+		}
+	})
+
+	t.Run("non-positive disables", func(t *testing.T) {
+		config := newConfig(Initial(10*time.Millisecond), NoJitter(), WarmUpTries(-2, time.Millisecond))
+		if got := config.delay(2); got != 10*time.Millisecond {
+			t.Errorf("expected the 10ms Initial delay, got %v", got)
+		}
+	})
+}
+
+func TestMultipliers(t *testing.T) {
+	t.Run("follows the factors", func(t *testing.T) {
+		config := newConfig(Initial(time.Millisecond), Max(time.Second), Multipliers(4, 4, 1.5), NoJitter())
+		want := []time.Duration{
+			time.Millisecond,      // attempt 2: Initial
+			4 * time.Millisecond,  // x4
+			16 * time.Millisecond, // x4
+			24 * time.Millisecond, // x1.5
+			36 * time.Millisecond, // x1.5, the last factor repeats
+			54 * time.Millisecond, // x1.5
+		}
+
+		for i, w := range want {
+			if got := config.delay(i + 2); got != w {
+				t.Errorf("attempt %d: delay = %v, want %v", i+2, got, w)
+			}
+		}
+		if got := config.delay(30); got != time.Second {
+			t.Errorf("attempt 30: expected delay clamped to 1s, got %v", got)
+		}
+	})
+
+	t.Run("first factor applies from the third attempt", func(t *testing.T) {
+		config := newConfig(Initial(time.Millisecond), Multipliers(10, 1), NoJitter())
+		if got := config.delay(2); got != time.Millisecond {
+			t.Errorf("attempt 2: expected Initial unscaled, got %v", got)
+		}
+		if got := config.delay(3); got != 10*time.Millisecond {
+			t.Errorf("attempt 3: expected factors[0] applied, got %v", got)
+		}
+	})
+
+	t.Run("single factor matches Multiplier", func(t *testing.T) {
+		a := newConfig(Initial(time.Millisecond), Multipliers(3), NoJitter())
+		b := newConfig(Initial(time.Millisecond), Multiplier(3), NoJitter())
+		for n := 2; n <= 8; n++ {
+			if a.delay(n) != b.delay(n) {
+				t.Errorf("attempt %d: Multipliers(3) = %v, Multiplier(3) = %v", n, a.delay(n), b.delay(n))
+			}
+		}
+	})
+
+	t.Run("jitter stays around the base", func(t *testing.T) {
+		config := newConfig(Initial(time.Millisecond), Multipliers(4, 4, 1.5), Jitter(0.5), withRandom(42))
+		for n, base := range map[int]time.Duration{3: 4 * time.Millisecond, 5: 24 * time.Millisecond} {
+			if got := config.delay(n); got < base/2 || got > base*3/2 {
+				t.Errorf("attempt %d: delay %v outside [%v, %v]", n, got, base/2, base*3/2)
+			}
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		var got []time.Duration
+		for attempt := range Attempts(Initial(time.Millisecond), Multipliers(2, 3), NoJitter(), Tries(5)) {
+			got = append(got, attempt.Delay)
+		}
+		want := []time.Duration{0, time.Millisecond, 2 * time.Millisecond, 6 * time.Millisecond, 18 * time.Millisecond}
+		if !slices.Equal(got, want) {
+			t.Errorf("expected delays %v, got %v", want, got)
+		}
+	})
+}
+
+func TestJitterApplied(t *testing.T) {
+	opts := []Option{
+		Initial(time.Millisecond),
+		Max(10 * time.Millisecond),
+		Jitter(0.5),
+		Tries(6),
+		withRandom(42),
+	}
+	base := newConfig(opts...)
+
+	t.Run("iterator", func(t *testing.T) {
+		for attempt := range Attempts(opts...) {
+			if attempt.Number == 1 {
+				if attempt.JitterApplied != 0 {
+					t.Errorf("expected no jitter on the first attempt, got %v", attempt.JitterApplied)
+				}
+				continue
+			}
+			if want := attempt.Delay - base.backoff(attempt.Number); attempt.JitterApplied != want {
+				t.Errorf("attempt %d: jitter = %v, want %v", attempt.Number, attempt.JitterApplied, want)
+			}
+		}
+	})
+
+	t.Run("OnRetry", func(t *testing.T) {
+		var jitters []time.Duration
+		nonZero := 0
+		_ = Retry(func() error {
+			return errors.New("always fail")
+		}, append(opts, OnRetry(func(attempt int, delay, jitter time.Duration) {
+			if want := delay - base.backoff(attempt); jitter != want {
+				t.Errorf("attempt %d: jitter = %v, want %v", attempt, jitter, want)
+			}
+			if jitter != 0 {
+				nonZero++
+			}
+			jitters = append(jitters, jitter)
+		}))...)
+
+		if len(jitters) != 5 {
+			t.Fatalf("expected 5 retries, got %d", len(jitters))
+		}
+		if nonZero == 0 {
+			t.Error("expected jitter to move some delays")
+		}
+
+		// Same seed, same jitter as the iterator
+		var fromIterator []time.Duration
+		for attempt := range Attempts(opts...) {
+			if attempt.Number > 1 {
+				fromIterator = append(fromIterator, attempt.JitterApplied)
+			}
+		}
+		if !slices.Equal(jitters, fromIterator) {
+			t.Errorf("expected Retry and the iterator to agree, got %v and %v", jitters, fromIterator)
+		}
+	})
+
+	t.Run("no jitter", func(t *testing.T) {
+		_ = Retry(func() error {
+			return errors.New("always fail")
+		}, Initial(time.Millisecond), NoJitter(), Tries(3), OnRetry(func(_ int, _, jitter time.Duration) {
+			if jitter != 0 {
+				t.Errorf("expected no jitter, got %v", jitter)
+			}
+		}))
+	})
+}
+
+// delayLogger records the delays scheduled by a retry loop
+type delayLogger struct {
+	delays []time.Duration
+}
+
+func (l *delayLogger) LogAttempt(int, error) {}
+
+func (l *delayLogger) LogRetry(_ int, delay time.Duration) {
+	l.delays = append(l.delays, delay)
+}
+
+func (l *delayLogger) LogGiveUp(int, error) {}
+
+func TestWithDeterministicJitter(t *testing.T) {
+	opts := []Option{
+		Initial(time.Millisecond),
+		Max(10 * time.Millisecond),
+		Jitter(0.5),
+		JitterCap(0.2),
+		Tries(6),
+	}
+
+	retryDelays := func(seed int64) []time.Duration {
+		logger := &delayLogger{}
+		_ = Retry(func() error {
+			return errors.New("always fail")
+		}, append(opts, WithLogger(logger), WithDeterministicJitter(seed))...)
+		return logger.delays
+	}
+	iteratorDelays := func(seed int64) []time.Duration {
+		var delays []time.Duration
+		for attempt := range AttemptsWithContext(context.Background(), append(opts, WithDeterministicJitter(seed))...) {
+			if attempt.Number > 1 {
+				delays = append(delays, attempt.Delay)
+			}
+		}
+		return delays
+	}
+
+	first := retryDelays(42)
+	if len(first) != 5 {
+		t.Fatalf("expected 5 delays, got %v", first)
+	}
+	if second := retryDelays(42); !slices.Equal(first, second) {
+		t.Errorf("expected identical Retry delays for the same seed, got %v and %v", first, second)
+	}
+	if iter := iteratorDelays(42); !slices.Equal(first, iter) {
+		t.Errorf("expected the iterator to match Retry, got %v and %v", first, iter)
+	}
+	if other := retryDelays(7); slices.Equal(first, other) {
+		t.Errorf("expected a different seed to change the delays, got %v", other)
+	}
+
+	t.Run("retrier restarts the sequence per call", func(t *testing.T) {
+		logger := &delayLogger{}
+		r := NewRetrier(append(opts, WithLogger(logger), WithDeterministicJitter(42))...)
+		for range 2 {
+			_ = r.Retry(func() error { return errors.New("always fail") })
+		}
+		if !slices.Equal(logger.delays[:5], first) || !slices.Equal(logger.delays[5:], first) {
+			t.Errorf("expected both calls to replay %v, got %v", first, logger.delays)
+		}
+	})
+}
+
+func assertGolden(t *testing.T, got []time.Duration) {
+	t.Helper()
+
+	// Delays in nanoseconds for seed 42
+	want := []time.Duration{
+		0,       // attempt 1
+		873028,  // base 1ms
+		1132000, // base 2ms
+		4416375, // base 4ms
+		5252912, // base 8ms
+		5219092, // base 10ms (Max)
+		6915966, // base 10ms (Max)
+		9064385, // base 10ms (Max)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d delays, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("attempt %d: delay = %v, want %v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestJitterCap(t *testing.T) {
+	t.Run("spreads capped delays around max", func(t *testing.T) {
+		const clients = 1000
+		maxInterval := time.Second
+
+		lowest, highest := time.Duration(1<<62), time.Duration(0)
+		var total time.Duration
+		for i := range clients {
+			config := newConfig(
+				Initial(maxInterval),
+				Max(maxInterval),
+				NoJitter(),
+				JitterCap(0.2),
+				withRandom(int64(i)),
+			)
+
+			delay := config.delay(6)
+			if delay < 800*time.Millisecond || delay > 1200*time.Millisecond {
+				t.Fatalf("client %d: capped delay %v outside [800ms, 1.2s]", i, delay)
+			}
+			lowest = min(lowest, delay)
+			highest = max(highest, delay)
+			total += delay
+		}
+
+		if lowest > 850*time.Millisecond || highest < 1150*time.Millisecond {
+			t.Errorf("expected delays to spread across the range, got [%v, %v]", lowest, highest)
+		}
+		if mean := total / clients; mean < 950*time.Millisecond || mean > 1050*time.Millisecond {
+			t.Errorf("expected mean near 1s, got %v", mean)
+		}
+	})
+
+	t.Run("composes with per-attempt jitter", func(t *testing.T) {
+		config := newConfig(Initial(time.Second), Max(time.Second), Jitter(0.5), JitterCap(0.2), withRandom(1))
+
+		for range 100 {
+			if delay := config.delay(4); delay > config.MaxInterval || delay < config.MaxInterval/2 {
+				t.Fatalf("delay %v outside [%v, %v]", delay, config.MaxInterval/2, config.MaxInterval)
+			}
+		}
+	})
+}
+
+func TestMaxJitter(t *testing.T) {
+	config := newConfig(Initial(time.Minute), Max(2*time.Minute), Jitter(0.5), MaxJitter(2*time.Second), withRandom(1))
+
+	lowest, highest := time.Duration(0), time.Duration(0)
+	for range 1000 {
+		_, jitter := config.jitteredDelay(2)
+		if jitter.Abs() > 2*time.Second {
+			t.Fatalf("jitter %v exceeds the 2s cap", jitter)
+		}
+		lowest = min(lowest, jitter)
+		highest = max(highest, jitter)
+	}
+
+	if lowest > -time.Second || highest < time.Second {
+		t.Errorf("expected jitter to spread across ±2s, got [%v, %v]", lowest, highest)
+	}
+}
@@ -0,0 +1,121 @@
+package ebo
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// RetryEach retries fn independently for every item in items.
+// Each item gets its own backoff schedule built from opts.
+// The returned map holds only the keys that failed, with their final error;
+// an empty map means every item succeeded.
+//
+// Items are processed one at a time unless WithConcurrency is given.
+// If the context set with WithContext is cancelled, items that were not
+// started yet are reported with the context error.
+//
+// Example:
+//
+//	failed := ebo.RetryEach(records, func(id string, rec Record) error {
+//	    return migrate(rec)
+//	}, ebo.Database(), ebo.WithConcurrency(8))
+//
+//	for id, err := range failed {
+//	    log.Printf("record %s failed: %v", id, err)
+//	}
+func RetryEach[K comparable, V any](items map[K]V, fn func(K, V) error, opts ...Option) map[K]error {
+	config := newConfig(opts...)
+	ctx := config.context()
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed = make(map[K]error)
+		sem    = make(chan struct{}, max(config.concurrency, 1))
+	)
+
+	record := func(key K, err error) {
+		mu.Lock()
+		failed[key] = err
+		mu.Unlock()
+	}
+
+	for key, value := range items {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			record(key, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := retry(config, func() error {
+				return fn(key, value)
+			}); err != nil {
+				record(key, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	return failed
+}
+
+// RetrySeq lazily retries fn for every item of src, yielding each item with
+// the final error of its retries (nil on success). Items are processed one at
+// a time as the caller ranges over the result, each with its own backoff
+// schedule built from opts. Breaking out of the loop stops pulling from src.
+//
+// Example:
+//
+//	for id, err := range ebo.RetrySeq(slices.Values(ids), func(id string) error {
+//	    return publish(id)
+//	}, ebo.Tries(3)) {
+//	    if err != nil {
+//	        log.Printf("publish %s: %v", id, err)
+//	    }
+//	}
+func RetrySeq[T any](src iter.Seq[T], fn func(T) error, opts ...Option) iter.Seq2[T, error] {
+	return retrySeq(context.Background(), src, func(_ context.Context, item T) error {
+		return fn(item)
+	}, opts)
+}
+
+// RetrySeqWithContext is RetrySeq with context support. ctx is passed to fn
+// and interrupts the backoff sleeps; once it is done, the item being retried
+// is yielded with the context error and the sequence ends.
+//
+// Example:
+//
+//	for order, err := range ebo.RetrySeqWithContext(ctx, orders, func(ctx context.Context, o Order) error {
+//	    return ship(ctx, o)
+//	}, ebo.API()) {
+//	    report(order, err)
+//	}
+func RetrySeqWithContext[T any](ctx context.Context, src iter.Seq[T], fn func(context.Context, T) error, opts ...Option) iter.Seq2[T, error] {
+	return retrySeq(ctx, src, fn, opts)
+}
+
+// retrySeq builds the sequence shared by RetrySeq and RetrySeqWithContext.
+func retrySeq[T any](ctx context.Context, src iter.Seq[T], fn func(context.Context, T) error, opts []Option) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		config := newConfig(append(opts[:len(opts):len(opts)], WithContext(ctx))...)
+
+		for item := range src {
+			if ctx.Err() != nil {
+				return
+			}
+			err := retry(config, func() error {
+				return fn(ctx, item)
+			})
+			if !yield(item, err) || ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryEach(t *testing.T) {
+	t.Run("mixed success and failure", func(t *testing.T) {
+		items := map[string]int{"a": 1, "b": 2, "c": 3}
+		attempts := make(map[string]int)
+		permanent := errors.New("permanent failure")
+
+		failed := RetryEach(items, func(key string, value int) error {
+			attempts[key]++
+			switch key {
+			case "b":
+				if attempts[key] < 2 {
+					return errors.New("temporary error")
+				}
+				return nil
+			case "c":
+				return permanent
+			}
+			return nil
+		}, Initial(10*time.Millisecond), Tries(3))
+
+		if len(failed) != 1 {
+			t.Fatalf("expected 1 failed item, got %d: %v", len(failed), failed)
+		}
+		if !errors.Is(failed["c"], permanent) {
+			t.Errorf("expected %v for key c, got %v", permanent, failed["c"])
+		}
+		if attempts["a"] != 1 {
+			t.Errorf("expected 1 attempt for a, got %d", attempts["a"])
+		}
+		if attempts["b"] != 2 {
+			t.Errorf("expected 2 attempts for b, got %d", attempts["b"])
+		}
+		if attempts["c"] != 3 {
+			t.Errorf("expected 3 attempts for c, got %d", attempts["c"])
+		}
+	})
+
+	t.Run("all succeed", func(t *testing.T) {
+		items := map[int]string{1: "x", 2: "y"}
+
+		failed := RetryEach(items, func(int, string) error {
+			return nil
+		})
+
+		if failed == nil || len(failed) != 0 {
+			t.Errorf("expected empty map, got %v", failed)
+		}
+	})
+}
+
+func TestRetryEachConcurrency(t *testing.T) {
+	t.Run("bounds in-flight operations", func(t *testing.T) {
+		items := make(map[int]int)
+		for i := range 20 {
+			items[i] = i
+		}
+
+		var inFlight, peak int32
+		failed := RetryEach(items, func(int, int) error {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+
+			for {
+				old := atomic.LoadInt32(&peak)
+				if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+					break
+				}
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}, WithConcurrency(3))
+
+		if len(failed) != 0 {
+			t.Errorf("expected no failures, got %v", failed)
+		}
+		if p := atomic.LoadInt32(&peak); p > 3 {
+			t.Errorf("expected at most 3 concurrent operations, got %d", p)
+		}
+		if p := atomic.LoadInt32(&peak); p < 2 {
+			t.Errorf("expected operations to run concurrently, peak was %d", p)
+		}
+	})
+
+	t.Run("cancellation returns partial results", func(t *testing.T) {
+		items := make(map[int]int)
+		for i := range 10 {
+			items[i] = i
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var completed int32
+
+		failed := RetryEach(items, func(int, int) error {
+			if atomic.AddInt32(&completed, 1) == 2 {
+				cancel()
+			}
+			return nil
+		}, WithContext(ctx), WithConcurrency(1))
+
+		done := int(atomic.LoadInt32(&completed))
+		if done+len(failed) != len(items) {
+			t.Errorf("expected %d completed plus failed items, got %d + %d", len(items), done, len(failed))
+		}
+		for key, err := range failed {
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled for key %d, got %v", key, err)
+			}
+		}
+		if len(failed) == 0 {
+			t.Error("expected some items to be skipped after cancellation")
+		}
+	})
+}
+
+func TestRetrySeq(t *testing.T) {
+	t.Run("retries each item", func(t *testing.T) {
+		attempts := make(map[string]int)
+		failure := errors.New("failure")
+
+		var got []string
+		for item, err := range RetrySeq(slices.Values([]string{"a", "b", "c"}), func(item string) error {
+			attempts[item]++
+			switch item {
+			case "b":
+				if attempts[item] < 3 {
+					return errors.New("temporary error")
+				}
+			case "c":
+				return failure
+			}
+			return nil
+		}, Initial(time.Millisecond), Tries(3)) {
+			got = append(got, item)
+			if item == "c" {
+				if !errors.Is(err, failure) {
+					t.Errorf("expected %v for c, got %v", failure, err)
+				}
+			} else if err != nil {
+				t.Errorf("expected %s to succeed, got %v", item, err)
+			}
+		}
+
+		if !slices.Equal(got, []string{"a", "b", "c"}) {
+			t.Errorf("expected items a, b, c in order, got %v", got)
+		}
+		if attempts["a"] != 1 || attempts["b"] != 3 || attempts["c"] != 3 {
+			t.Errorf("unexpected attempts: %v", attempts)
+		}
+	})
+
+	t.Run("lazy", func(t *testing.T) {
+		calls := 0
+		for range RetrySeq(slices.Values([]int{1, 2, 3}), func(int) error {
+			calls++
+			return nil
+		}) {
+			break
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call after break, got %d", calls)
+		}
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var got []int
+		var errs []error
+		for item, err := range RetrySeqWithContext(ctx, slices.Values([]int{1, 2, 3}), func(ctx context.Context, item int) error {
+			if item == 2 {
+				cancel()
+				return ctx.Err()
+			}
+			return nil
+		}, Initial(time.Millisecond), Tries(3)) {
+			got = append(got, item)
+			errs = append(errs, err)
+		}
+
+		if !slices.Equal(got, []int{1, 2}) {
+			t.Fatalf("expected items 1, 2, got %v", got)
+		}
+		if errs[0] != nil || !errors.Is(errs[1], context.Canceled) {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+	})
+}
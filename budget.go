@@ -0,0 +1,51 @@
+package ebo
+
+import (
+	"sync"
+	"time"
+)
+
+// TimeBudget is a pool of time shared by several retry loops, such as the
+// calls an API aggregator fans out under one SLA. Every retry loop using it
+// through WithTimeBudget draws the time it spends, attempts and sleeps alike,
+// and stops once the pool is empty. Concurrent loops draw
+// from it at the same time, so it bounds their combined time.
+// A TimeBudget is safe for concurrent use.
+//
+// Example:
+//
+//	budget := ebo.NewTimeBudget(800 * time.Millisecond)
+//
+//	user, err := ebo.RetryValue(fetchUser, ebo.WithTimeBudget(budget))
+//	// ...
+//	orders, err := ebo.RetryValue(fetchOrders, ebo.WithTimeBudget(budget))
+type TimeBudget struct {
+	mu        sync.Mutex
+	remaining time.Duration
+}
+
+// NewTimeBudget returns a TimeBudget holding total.
+func NewTimeBudget(total time.Duration) *TimeBudget {
+	return &TimeBudget{remaining: total}
+}
+
+// Remaining returns the time left in the budget, which is negative once
+// retry loops have overdrawn it.
+func (b *TimeBudget) Remaining() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining
+}
+
+// spendSince draws the time elapsed since *mark, moves *mark to now and
+// returns the time left.
+func (b *TimeBudget) spendSince(mark *time.Time) time.Duration {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remaining -= now.Sub(*mark)
+	*mark = now
+	return b.remaining
+}
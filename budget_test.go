@@ -0,0 +1,92 @@
+package ebo
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeBudget(t *testing.T) {
+	t.Run("sequential retries share the budget", func(t *testing.T) {
+		budget := NewTimeBudget(60 * time.Millisecond)
+		failing := func(calls *int) RetryableFunc {
+			return func() error {
+				*calls++
+				time.Sleep(10 * time.Millisecond)
+				return errors.New("backend unavailable")
+			}
+		}
+
+		// The first retry uses roughly half of the budget
+		first := 0
+		_ = Retry(failing(&first), WithTimeBudget(budget), Initial(time.Millisecond), NoJitter(), Tries(3))
+		if first != 3 {
+			t.Fatalf("expected 3 attempts from the first retry, got %d", first)
+		}
+		left := budget.Remaining()
+		if left <= 0 || left > 30*time.Millisecond {
+			t.Fatalf("expected 0-30ms left after the first retry, got %v", left)
+		}
+
+		// The second one only gets what is left, however many tries it is allowed
+		second := 0
+		start := time.Now()
+		err := Retry(failing(&second), WithTimeBudget(budget), Initial(time.Millisecond), NoJitter(), Forever())
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if second < 1 || second > 3 {
+			t.Errorf("expected 1-3 attempts from the remaining budget, got %d", second)
+		}
+		if elapsed := time.Since(start); elapsed > left+25*time.Millisecond {
+			t.Errorf("expected the second retry to stop near %v, took %v", left, elapsed)
+		}
+
+		// Once exhausted, fn is not called at all
+		third := 0
+		err = Retry(failing(&third), WithTimeBudget(NewTimeBudget(0)))
+		if !errors.Is(err, ErrMaxElapsed) {
+			t.Errorf("expected ErrMaxElapsed, got %v", err)
+		}
+		if third != 0 {
+			t.Errorf("expected no attempts, got %d", third)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		budget := NewTimeBudget(30 * time.Millisecond)
+		for range Attempts(WithTimeBudget(budget), Initial(time.Millisecond), NoJitter(), Forever()) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if left := budget.Remaining(); left > 10*time.Millisecond {
+			t.Errorf("expected the iterator to use up the budget, %v left", left)
+		}
+
+		err := DoWithAttempts(func(*Attempt) error { return nil }, WithTimeBudget(NewTimeBudget(0)))
+		if !errors.Is(err, ErrNoAttempts) || !errors.Is(err, ErrMaxElapsed) {
+			t.Errorf("expected ErrNoAttempts and ErrMaxElapsed, got %v", err)
+		}
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		budget := NewTimeBudget(time.Second)
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = Retry(func() error {
+					time.Sleep(time.Millisecond)
+					return nil
+				}, WithTimeBudget(budget))
+			}()
+		}
+		wg.Wait()
+
+		if left := budget.Remaining(); left >= time.Second-8*time.Millisecond {
+			t.Errorf("expected all 8 calls to draw from the budget, %v left", left)
+		}
+	})
+}
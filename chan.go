@@ -0,0 +1,64 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrChannelFull is retried by RetrySend while the channel has no room.
+	ErrChannelFull = errors.New("ebo: channel full")
+	// ErrChannelEmpty is retried by RetryReceive while the channel has no value.
+	ErrChannelEmpty = errors.New("ebo: channel empty")
+	// ErrChannelClosed is returned by RetryReceive when the channel is closed.
+	ErrChannelClosed = errors.New("ebo: channel closed")
+)
+
+// RetrySend attempts a non-blocking send of v on ch, backing off and trying
+// again while the channel is full. It returns nil once v is sent, ctx.Err()
+// if ctx is done, or ErrChannelFull when the attempts run out. Like a plain
+// send, it panics if ch is closed.
+//
+// Example:
+//
+//	if err := ebo.RetrySend(ctx, out, item, ebo.Initial(10*time.Millisecond), ebo.Tries(20)); err != nil {
+//	    return fmt.Errorf("downstream stalled: %w", err)
+//	}
+func RetrySend[T any](ctx context.Context, ch chan<- T, v T, opts ...Option) error {
+	return RetryCtx(ctx, func() error {
+		select {
+		case ch <- v:
+			return nil
+		default:
+			return ErrChannelFull
+		}
+	}, opts...)
+}
+
+// RetryReceive attempts a non-blocking receive from ch, backing off and trying
+// again while the channel is empty. It returns the received value, or an error
+// when ctx is done or the attempts run out (ErrChannelEmpty). A closed channel
+// stops retrying immediately with ErrChannelClosed.
+//
+// Example:
+//
+//	job, err := ebo.RetryReceive(ctx, jobs, ebo.Initial(10*time.Millisecond), ebo.Tries(20))
+//	if errors.Is(err, ebo.ErrChannelClosed) {
+//	    return nil // no more work
+//	}
+func RetryReceive[T any](ctx context.Context, ch <-chan T, opts ...Option) (T, error) {
+	var v T
+	err := RetryCtx(ctx, func() error {
+		select {
+		case got, ok := <-ch:
+			if !ok {
+				return &permanentError{ErrChannelClosed}
+			}
+			v = got
+			return nil
+		default:
+			return ErrChannelEmpty
+		}
+	}, opts...)
+	return v, err
+}
@@ -0,0 +1,80 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetrySend(t *testing.T) {
+	t.Run("sends once room is available", func(t *testing.T) {
+		ch := make(chan int, 1)
+		ch <- 1
+		time.AfterFunc(20*time.Millisecond, func() { <-ch })
+
+		err := RetrySend(context.Background(), ch, 2, Initial(5*time.Millisecond), Max(10*time.Millisecond), Tries(50))
+		if err != nil {
+			t.Fatalf("expected send to succeed, got %v", err)
+		}
+		if got := <-ch; got != 2 {
+			t.Errorf("expected 2 in channel, got %d", got)
+		}
+	})
+
+	t.Run("gives up on a full channel", func(t *testing.T) {
+		ch := make(chan int, 1)
+		ch <- 1
+
+		err := RetrySend(context.Background(), ch, 2, Initial(time.Millisecond), Tries(3))
+		if !errors.Is(err, ErrChannelFull) {
+			t.Errorf("expected ErrChannelFull, got %v", err)
+		}
+	})
+
+	t.Run("stops on cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := RetrySend(ctx, make(chan int), 1, Initial(time.Second))
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
+
+func TestRetryReceive(t *testing.T) {
+	t.Run("receives once a value arrives", func(t *testing.T) {
+		ch := make(chan string, 1)
+		time.AfterFunc(20*time.Millisecond, func() { ch <- "ready" })
+
+		v, err := RetryReceive(context.Background(), ch, Initial(5*time.Millisecond), Max(10*time.Millisecond), Tries(50))
+		if err != nil {
+			t.Fatalf("expected receive to succeed, got %v", err)
+		}
+		if v != "ready" {
+			t.Errorf("expected 'ready', got %q", v)
+		}
+	})
+
+	t.Run("gives up on an empty channel", func(t *testing.T) {
+		_, err := RetryReceive(context.Background(), make(chan int), Initial(time.Millisecond), Tries(3))
+		if !errors.Is(err, ErrChannelEmpty) {
+			t.Errorf("expected ErrChannelEmpty, got %v", err)
+		}
+	})
+
+	t.Run("closed channel is permanent", func(t *testing.T) {
+		ch := make(chan int)
+		close(ch)
+
+		start := time.Now()
+		_, err := RetryReceive(context.Background(), ch, Tries(5), Initial(time.Second))
+		if !errors.Is(err, ErrChannelClosed) {
+			t.Errorf("expected ErrChannelClosed, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("expected no retry on a closed channel, took %v", elapsed)
+		}
+	})
+}
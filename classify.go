@@ -0,0 +1,105 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+)
+
+// Classification describes how a retry loop should treat an error.
+type Classification int
+
+// Possible error classifications
+const (
+	Unknown   Classification = iota // No opinion, the error is retried
+	Retryable                       // The error is transient and is retried
+	Permanent                       // The error is final and stops retrying
+)
+
+// ErrorClassifier classifies errors returned by a retried function.
+// It lets a codebase keep one central decision on which errors are worth retrying.
+type ErrorClassifier func(error) Classification
+
+// ConditionClassifier adapts a RetryWithCondition style condition to an
+// ErrorClassifier: errors satisfying condition are Retryable, all others Permanent.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.WithClassifier(ebo.ConditionClassifier(isRetryable)))
+func ConditionClassifier(condition func(error) bool) ErrorClassifier {
+	return func(err error) Classification {
+		if condition(err) {
+			return Retryable
+		}
+		return Permanent
+	}
+}
+
+// permanent reports whether err must not be retried, either because it was
+// marked permanent, because it is a context error with StopOnDeadlineExceeded,
+// because the aggregate error policy rejects it or because the configured
+// detector or classifier says so.
+// It returns the error that should be reported to the caller.
+func (c *RetryConfig) permanent(err error) (error, bool) {
+	var permErr *permanentError
+	if errors.As(err, &permErr) {
+		return permErr.err, true
+	}
+	if c.permanentDetector != nil && c.permanentDetector(err) {
+		return err, true
+	}
+	if c.stopOnContextErr && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+		return err, true
+	}
+	if c.aggregateClassifier != nil && c.aggregatePermanent(err) {
+		return err, true
+	}
+	if c.classifier != nil && c.classifier(err) == Permanent {
+		return err, true
+	}
+	return err, false
+}
+
+// AggregatePolicy decides whether an aggregate error, such as one built with
+// errors.Join, is retried based on its sub-errors. See WithAggregateErrorPolicy.
+type AggregatePolicy int
+
+// Possible aggregate error policies
+const (
+	AllRetryable AggregatePolicy = iota // Retry only if every sub-error is retryable
+	AnyRetryable                        // Retry if at least one sub-error is retryable
+)
+
+// aggregatePermanent reports whether err is an aggregate error that the
+// aggregate policy does not retry. Other errors are left to the other checks.
+func (c *RetryConfig) aggregatePermanent(err error) bool {
+	errs := leafErrors(err)
+	if len(errs) < 2 {
+		return false
+	}
+
+	retryable := 0
+	for _, e := range errs {
+		if c.aggregateClassifier(e) != Permanent {
+			retryable++
+		}
+	}
+	if c.aggregatePolicy == AnyRetryable {
+		return retryable == 0
+	}
+	return retryable < len(errs)
+}
+
+// leafErrors flattens the errors joined into err, through any wrapping,
+// returning err alone if it does not join several errors.
+func leafErrors(err error) []error {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if joined, ok := e.(interface{ Unwrap() []error }); ok {
+			var errs []error
+			for _, sub := range joined.Unwrap() {
+				errs = append(errs, leafErrors(sub)...)
+			}
+			return errs
+		}
+	}
+	return []error{err}
+}
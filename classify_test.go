@@ -0,0 +1,200 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var (
+	errTimeout  = errors.New("timeout")
+	errNotFound = errors.New("not found")
+)
+
+func testClassifier(err error) Classification {
+	switch {
+	case errors.Is(err, errNotFound):
+		return Permanent
+	case errors.Is(err, errTimeout):
+		return Retryable
+	}
+	return Unknown
+}
+
+func TestWithClassifier(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts int
+	}{
+		{"retryable", errTimeout, 3},
+		{"permanent", errNotFound, 1},
+		{"unknown", errors.New("something else"), 3},
+		{"wrapped permanent", fmt.Errorf("load user: %w", errNotFound), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Retry(func() error {
+				attempts++
+				return tt.err
+			}, WithClassifier(testClassifier), Initial(time.Millisecond), Tries(3))
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+		})
+	}
+
+	t.Run("iterator helper", func(t *testing.T) {
+		attempts := 0
+		err := DoWithAttempts(func(*Attempt) error {
+			attempts++
+			return errNotFound
+		}, WithClassifier(testClassifier), Initial(time.Millisecond), Tries(3))
+
+		if !errors.Is(err, errNotFound) {
+			t.Errorf("expected %v, got %v", errNotFound, err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+	})
+}
+
+func TestConditionClassifier(t *testing.T) {
+	classify := ConditionClassifier(func(err error) bool {
+		return errors.Is(err, errTimeout)
+	})
+
+	if got := classify(errTimeout); got != Retryable {
+		t.Errorf("expected Retryable, got %v", got)
+	}
+	if got := classify(errNotFound); got != Permanent {
+		t.Errorf("expected Permanent, got %v", got)
+	}
+}
+
+type ErrForbidden struct {
+	Resource string
+}
+
+func (e *ErrForbidden) Error() string {
+	return "forbidden: " + e.Resource
+}
+
+func TestWithPermanentDetector(t *testing.T) {
+	isForbidden := func(err error) bool {
+		var forbidden *ErrForbidden
+		return errors.As(err, &forbidden)
+	}
+
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts int
+	}{
+		{"detected", &ErrForbidden{Resource: "invoice"}, 1},
+		{"wrapped detected", fmt.Errorf("load invoice: %w", &ErrForbidden{Resource: "invoice"}), 1},
+		{"not detected", errTimeout, 3},
+		{"classifier still applies", errNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Retry(func() error {
+				attempts++
+				return tt.err
+			}, WithPermanentDetector(isForbidden), WithClassifier(testClassifier), Initial(time.Millisecond), Tries(3))
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestStopOnDeadlineExceeded(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		opts         []Option
+		wantAttempts int
+	}{
+		{"deadline exceeded", context.DeadlineExceeded, []Option{StopOnDeadlineExceeded()}, 1},
+		{"wrapped deadline exceeded", fmt.Errorf("call backend: %w", context.DeadlineExceeded), []Option{StopOnDeadlineExceeded()}, 1},
+		{"canceled", context.Canceled, []Option{StopOnDeadlineExceeded()}, 1},
+		{"other errors retried", errTimeout, []Option{StopOnDeadlineExceeded()}, 3},
+		{"retried without the option", context.DeadlineExceeded, nil, 3},
+		{"detector still applies", &ErrForbidden{Resource: "invoice"}, []Option{
+			StopOnDeadlineExceeded(),
+			WithPermanentDetector(func(err error) bool {
+				var forbidden *ErrForbidden
+				return errors.As(err, &forbidden)
+			}),
+		}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Retry(func() error {
+				attempts++
+				return tt.err
+			}, append(tt.opts, Initial(time.Millisecond), Tries(3))...)
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestWithAggregateErrorPolicy(t *testing.T) {
+	mixed := errors.Join(errNotFound, errTimeout)
+
+	tests := []struct {
+		name         string
+		policy       AggregatePolicy
+		err          error
+		wantAttempts int
+	}{
+		{"all retryable with a permanent sub-error", AllRetryable, mixed, 1},
+		{"any retryable with a transient sub-error", AnyRetryable, mixed, 3},
+		{"all retryable with transient sub-errors", AllRetryable, errors.Join(errTimeout, errTimeout), 3},
+		{"any retryable with permanent sub-errors", AnyRetryable, errors.Join(errNotFound, errNotFound), 1},
+		{"wrapped aggregate", AllRetryable, fmt.Errorf("sync batch: %w", mixed), 1},
+		{"nested aggregate", AnyRetryable, errors.Join(errNotFound, errors.Join(errNotFound, errTimeout)), 3},
+		{"single error unaffected", AllRetryable, errNotFound, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Retry(func() error {
+				attempts++
+				return tt.err
+			}, WithAggregateErrorPolicy(tt.policy, testClassifier), Initial(time.Millisecond), Tries(3))
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package ebo
+
+import "time"
+
+// Clock provides the timers the retry loops sleep on between attempts. The
+// default uses real timers; a fake Clock set with WithClock lets tests advance
+// through the backoff of Retry, the iterators and RetryMiddleware without
+// actually waiting.
+type Clock interface {
+	// After returns a channel that receives once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
@@ -0,0 +1,58 @@
+package ebo
+
+import (
+	"context"
+	"time"
+)
+
+// RetryState describes the attempt that is currently running.
+type RetryState struct {
+	Attempt int           // Attempt number, starting from 1
+	Elapsed time.Duration // Time elapsed since the first attempt started
+}
+
+// retryStateKey is the context key under which RetryState is stored.
+type retryStateKey struct{}
+
+// withRetryState returns a copy of ctx carrying state.
+func withRetryState(ctx context.Context, state RetryState) context.Context {
+	return context.WithValue(ctx, retryStateKey{}, state)
+}
+
+// FromContext returns the RetryState stored in ctx by the iterators.
+// Every Attempt.Context yielded by Attempts, AttemptsWithContext and
+// DoWithAttempts carries the state of its attempt, so code deep in the call
+// stack can log the attempt number without extra parameters. So does the
+// context given to a Tracer. Retry, RetryCtx and RetryValue call functions
+// that take no context, so a context captured by them carries no state; use
+// DoWithAttemptsContext to pass it down.
+//
+// Example:
+//
+//	err := ebo.DoWithAttemptsContext(ctx, func(attempt *ebo.Attempt) error {
+//	    return fetch(attempt.Context)
+//	})
+//
+//	func fetch(ctx context.Context) error {
+//	    if state, ok := ebo.FromContext(ctx); ok {
+//	        log.Printf("fetch attempt %d after %v", state.Attempt, state.Elapsed)
+//	    }
+//	    ...
+//	}
+func FromContext(ctx context.Context) (RetryState, bool) {
+	state, ok := ctx.Value(retryStateKey{}).(RetryState)
+	return state, ok
+}
+
+// watchContext arranges for cleanup to run once if ctx ends before the
+// returned function is called.
+func watchContext(ctx context.Context, cleanup func()) func() {
+	stop := context.AfterFunc(ctx, cleanup)
+	return func() {
+		// Cancelling ctx makes ctx.Err visible before it starts the AfterFunc,
+		// so the loop may have stopped the hook before it could run
+		if stop() && ctx.Err() != nil {
+			cleanup()
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Run("state on third attempt", func(t *testing.T) {
+		var state RetryState
+		var ok bool
+
+		err := DoWithAttemptsContext(context.Background(), func(attempt *Attempt) error {
+			if attempt.Number < 3 {
+				return errors.New("temporary error")
+			}
+			state, ok = FromContext(attempt.Context)
+			return nil
+		}, Initial(10*time.Millisecond), NoJitter())
+
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		if !ok {
+			t.Fatal("expected retry state in context")
+		}
+		if state.Attempt != 3 {
+			t.Errorf("expected attempt 3, got %d", state.Attempt)
+		}
+		// 10ms before the second attempt and 20ms before the third
+		if state.Elapsed < 30*time.Millisecond {
+			t.Errorf("expected elapsed of at least 30ms, got %v", state.Elapsed)
+		}
+	})
+
+	t.Run("state in plain iterator", func(t *testing.T) {
+		for attempt := range Attempts(Tries(2), Initial(time.Millisecond)) {
+			state, ok := FromContext(attempt.Context)
+			if !ok {
+				t.Fatal("expected retry state in context")
+			}
+			if state.Attempt != attempt.Number {
+				t.Errorf("expected attempt %d, got %d", attempt.Number, state.Attempt)
+			}
+		}
+	})
+
+	t.Run("parent context values are preserved", func(t *testing.T) {
+		type key struct{}
+		ctx := context.WithValue(context.Background(), key{}, "value")
+
+		for attempt := range AttemptsWithContext(ctx, Tries(1)) {
+			if attempt.Context.Value(key{}) != "value" {
+				t.Error("expected parent context value to be preserved")
+			}
+		}
+	})
+
+	t.Run("missing state", func(t *testing.T) {
+		if _, ok := FromContext(context.Background()); ok {
+			t.Error("expected no retry state in background context")
+		}
+	})
+}
+
+func TestOnContextDone(t *testing.T) {
+	t.Run("cancelled mid-loop", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		calls := int32(0)
+		done := make(chan struct{}, 2)
+		attempts := 0
+		err := RetryCtx(ctx, func() error {
+			attempts++
+			if attempts == 2 {
+				cancel()
+			}
+			return errors.New("temporary")
+		}, Initial(time.Millisecond), Tries(5), OnContextDone(func() {
+			atomic.AddInt32(&calls, 1)
+			done <- struct{}{}
+		}))
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("expected the cleanup to run")
+		}
+		time.Sleep(20 * time.Millisecond)
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("expected the cleanup to run once, got %d", got)
+		}
+	})
+
+	t.Run("not called after success", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		calls := int32(0)
+		err := RetryCtx(ctx, func() error {
+			return nil
+		}, OnContextDone(func() {
+			atomic.AddInt32(&calls, 1)
+		}))
+		cancel()
+
+		if err != nil {
+			t.Errorf("expected success, got %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if got := atomic.LoadInt32(&calls); got != 0 {
+			t.Errorf("expected no cleanup, got %d calls", got)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package ebo
+
+import (
+	"context"
+	"time"
+)
+
+// EventKind describes what happened in a RetryEvent.
+type EventKind int
+
+// Possible event kinds
+const (
+	EventAttempt EventKind = iota // An attempt failed with Err
+	EventRetry                    // The next attempt starts after Delay
+	EventSuccess                  // The attempt succeeded or returned ErrStop
+	EventGiveUp                   // Retrying stopped without success, with the final Err
+)
+
+// String returns a short name for the kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventAttempt:
+		return "attempt"
+	case EventRetry:
+		return "retry"
+	case EventSuccess:
+		return "success"
+	case EventGiveUp:
+		return "give_up"
+	default:
+		return "unknown"
+	}
+}
+
+// RetryEvent is a step of a retry loop, as sent by RetryWithEvents.
+type RetryEvent struct {
+	Kind    EventKind
+	Attempt int           // The attempt the event is about; for EventRetry, the upcoming one
+	Err     error         // The attempt's error; for EventRetry, the error being retried
+	Delay   time.Duration // The wait before the attempt (EventRetry only)
+}
+
+// RetryWithEvents runs Retry and sends a RetryEvent on events for every
+// failed attempt, every retry and the final success or give-up, e.g. to drive
+// a progress UI. Events are sent without blocking and dropped when events is
+// full, so a slow reader never holds up retrying; BlockOnEvents waits for
+// room instead. events is not closed. A logger set with WithLogger still
+// receives every event.
+//
+// Example:
+//
+//	events := make(chan ebo.RetryEvent, 16)
+//	go func() {
+//	    for e := range events {
+//	        progress.Set(e.Kind.String(), e.Attempt)
+//	    }
+//	}()
+//	err := ebo.RetryWithEvents(upload, events, ebo.Tries(5))
+//	close(events)
+func RetryWithEvents(fn RetryableFunc, events chan<- RetryEvent, opts ...Option) error {
+	config := newConfig(opts...)
+	config.logger = &eventLogger{
+		events: events,
+		ctx:    config.context(),
+		block:  config.blockEvents,
+		next:   config.logger,
+	}
+	return retry(config, fn)
+}
+
+// eventLogger turns retry loop events into RetryEvents on a channel and
+// forwards them to the next logger, if any.
+type eventLogger struct {
+	events  chan<- RetryEvent
+	ctx     context.Context // Ends blocking sends
+	block   bool
+	next    RetryLogger
+	lastErr error
+}
+
+func (l *eventLogger) LogAttempt(attempt int, err error) {
+	l.lastErr = err
+	if err != nil {
+		l.send(RetryEvent{Kind: EventAttempt, Attempt: attempt, Err: err})
+	} else {
+		l.send(RetryEvent{Kind: EventSuccess, Attempt: attempt})
+	}
+	if l.next != nil {
+		l.next.LogAttempt(attempt, err)
+	}
+}
+
+func (l *eventLogger) LogRetry(attempt int, delay time.Duration) {
+	l.send(RetryEvent{Kind: EventRetry, Attempt: attempt, Err: l.lastErr, Delay: delay})
+	if l.next != nil {
+		l.next.LogRetry(attempt, delay)
+	}
+}
+
+func (l *eventLogger) LogGiveUp(attempts int, err error) {
+	l.send(RetryEvent{Kind: EventGiveUp, Attempt: attempts, Err: err})
+	if l.next != nil {
+		l.next.LogGiveUp(attempts, err)
+	}
+}
+
+// send delivers e, dropping it when events is full unless blocking.
+func (l *eventLogger) send(e RetryEvent) {
+	if !l.block {
+		select {
+		case l.events <- e:
+		default:
+		}
+		return
+	}
+	select {
+	case l.events <- e:
+	case <-l.ctx.Done():
+	}
+}
@@ -0,0 +1,138 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetryWithEvents(t *testing.T) {
+	t.Run("fail twice then succeed", func(t *testing.T) {
+		failure := errors.New("failure")
+		events := make(chan RetryEvent, 16)
+		calls := 0
+		err := RetryWithEvents(func() error {
+			if calls++; calls <= 2 {
+				return failure
+			}
+			return nil
+		}, events, Initial(time.Millisecond), NoJitter(), Tries(5))
+		close(events)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var kinds []EventKind
+		var got []RetryEvent
+		for e := range events {
+			kinds = append(kinds, e.Kind)
+			got = append(got, e)
+		}
+		want := []EventKind{EventAttempt, EventRetry, EventAttempt, EventRetry, EventSuccess}
+		if !slices.Equal(kinds, want) {
+			t.Fatalf("expected events %v, got %v", want, kinds)
+		}
+		if got[0].Attempt != 1 || !errors.Is(got[0].Err, failure) {
+			t.Errorf("expected attempt 1 to fail, got %+v", got[0])
+		}
+		if got[1].Attempt != 2 || got[1].Delay != time.Millisecond || !errors.Is(got[1].Err, failure) {
+			t.Errorf("expected a 1ms retry before attempt 2, got %+v", got[1])
+		}
+		if got[3].Attempt != 3 || got[3].Delay != 2*time.Millisecond {
+			t.Errorf("expected a 2ms retry before attempt 3, got %+v", got[3])
+		}
+		if got[4].Attempt != 3 || got[4].Err != nil {
+			t.Errorf("expected attempt 3 to succeed, got %+v", got[4])
+		}
+	})
+
+	t.Run("ErrStop ends with success", func(t *testing.T) {
+		events := make(chan RetryEvent, 16)
+		calls := 0
+		err := RetryWithEvents(func() error {
+			if calls++; calls == 1 {
+				return errors.New("failure")
+			}
+			return ErrStop
+		}, events, Initial(time.Millisecond), Tries(5))
+		close(events)
+
+		var kinds []EventKind
+		var last RetryEvent
+		for e := range events {
+			kinds = append(kinds, e.Kind)
+			last = e
+		}
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if want := []EventKind{EventAttempt, EventRetry, EventSuccess}; !slices.Equal(kinds, want) {
+			t.Errorf("expected events %v, got %v", want, kinds)
+		}
+		if last.Attempt != 2 || last.Err != nil {
+			t.Errorf("expected attempt 2 to succeed, got %+v", last)
+		}
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		failure := errors.New("failure")
+		events := make(chan RetryEvent, 16)
+		err := RetryWithEvents(func() error { return failure }, events, Initial(time.Millisecond), Tries(2))
+		close(events)
+
+		var last RetryEvent
+		for e := range events {
+			last = e
+		}
+		if !errors.Is(err, failure) || last.Kind != EventGiveUp || last.Attempt != 2 || !errors.Is(last.Err, failure) {
+			t.Errorf("expected a give-up after 2 attempts, got %+v and %v", last, err)
+		}
+	})
+
+	t.Run("drops events when full", func(t *testing.T) {
+		events := make(chan RetryEvent, 1)
+		err := RetryWithEvents(func() error { return errors.New("failure") }, events, Initial(time.Millisecond), Tries(3))
+
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if e := <-events; e.Kind != EventAttempt || e.Attempt != 1 {
+			t.Errorf("expected only the first event, got %+v", e)
+		}
+	})
+
+	t.Run("blocks on events", func(t *testing.T) {
+		events := make(chan RetryEvent)
+		done := make(chan error, 1)
+		go func() {
+			done <- RetryWithEvents(func() error { return errors.New("failure") }, events,
+				Initial(time.Millisecond), Tries(2), BlockOnEvents())
+		}()
+
+		var kinds []EventKind
+		for range 4 {
+			kinds = append(kinds, (<-events).Kind)
+		}
+		<-done
+
+		want := []EventKind{EventAttempt, EventRetry, EventAttempt, EventGiveUp}
+		if !slices.Equal(kinds, want) {
+			t.Errorf("expected events %v, got %v", want, kinds)
+		}
+	})
+
+	t.Run("blocking ends with the context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		err := RetryWithEvents(func() error { return errors.New("failure") }, make(chan RetryEvent),
+			Initial(time.Millisecond), Tries(2), BlockOnEvents(), WithContext(ctx))
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
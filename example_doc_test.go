@@ -130,3 +130,20 @@ func ExampleRetryWithLogging() {
 	// Output:
 	// Success with logging
 }
+
+func ExampleRetryCtx() {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	err := ebo.RetryCtx(ctx, func() error {
+		fmt.Println("Trying with context...")
+		return nil
+	}, ebo.Initial(100*time.Millisecond))
+
+	if err == nil {
+		fmt.Println("Success!")
+	}
+	// Output:
+	// Trying with context...
+	// Success!
+}
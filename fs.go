@@ -0,0 +1,50 @@
+package ebo
+
+import (
+	"errors"
+	"syscall"
+)
+
+// FSClassifier is the ErrorClassifier RetryFS uses. Errors that network file
+// systems return transiently (EAGAIN, EBUSY, EINTR, EIO, ESTALE, ETIMEDOUT)
+// are Retryable, errors that retrying cannot fix (ENOSPC, EACCES, EPERM,
+// EROFS) are Permanent and all others are Unknown, so they are retried.
+// Errors are matched with errors.Is, so the *fs.PathError returned by the os
+// package is unwrapped.
+//
+// Example:
+//
+//	// Also give up on missing files
+//	err := ebo.RetryFS(fn, ebo.WithClassifier(func(err error) ebo.Classification {
+//	    if errors.Is(err, fs.ErrNotExist) {
+//	        return ebo.Permanent
+//	    }
+//	    return ebo.FSClassifier(err)
+//	}))
+func FSClassifier(err error) Classification {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return Unknown
+	}
+
+	switch errno {
+	case syscall.EAGAIN, syscall.EBUSY, syscall.EINTR, syscall.EIO, syscall.ESTALE, syscall.ETIMEDOUT:
+		return Retryable
+	case syscall.ENOSPC, syscall.EACCES, syscall.EPERM, syscall.EROFS:
+		return Permanent
+	}
+	return Unknown
+}
+
+// RetryFS retries a file system operation with exponential backoff, stopping
+// on the errors FSClassifier reports as Permanent. A WithClassifier option in
+// opts replaces FSClassifier.
+//
+// Example:
+//
+//	err := ebo.RetryFS(func() error {
+//	    return os.Rename(tmp, "/mnt/nfs/report.csv")
+//	}, ebo.Tries(5), ebo.Initial(50*time.Millisecond))
+func RetryFS(fn func() error, opts ...Option) error {
+	return Retry(fn, append([]Option{WithClassifier(FSClassifier)}, opts...)...)
+}
@@ -0,0 +1,59 @@
+package ebo
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFSClassifier(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Classification
+	}{
+		{syscall.EAGAIN, Retryable},
+		{syscall.EBUSY, Retryable},
+		{&fs.PathError{Op: "open", Path: "/mnt/nfs/a", Err: syscall.EIO}, Retryable},
+		{syscall.ENOSPC, Permanent},
+		{&fs.PathError{Op: "open", Path: "/mnt/nfs/a", Err: syscall.EACCES}, Permanent},
+		{syscall.ENOENT, Unknown},
+		{errors.New("other"), Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := FSClassifier(tt.err); got != tt.want {
+			t.Errorf("FSClassifier(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryFS(t *testing.T) {
+	t.Run("retries transient errors", func(t *testing.T) {
+		calls := 0
+		err := RetryFS(func() error {
+			calls++
+			if calls <= 2 {
+				return &fs.PathError{Op: "write", Path: "/mnt/nfs/a", Err: syscall.EAGAIN}
+			}
+			return nil
+		}, Initial(time.Millisecond), Tries(5))
+
+		if err != nil || calls != 3 {
+			t.Errorf("expected success on the third call, got %d calls and %v", calls, err)
+		}
+	})
+
+	t.Run("does not retry permission errors", func(t *testing.T) {
+		calls := 0
+		err := RetryFS(func() error {
+			calls++
+			return &fs.PathError{Op: "open", Path: "/mnt/nfs/a", Err: syscall.EACCES}
+		}, Initial(time.Millisecond), Tries(5))
+
+		if !errors.Is(err, syscall.EACCES) || calls != 1 {
+			t.Errorf("expected EACCES after one call, got %d calls and %v", calls, err)
+		}
+	})
+}
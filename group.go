@@ -0,0 +1,65 @@
+package ebo
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs retried operations concurrently, like errgroup.Group: every
+// operation is retried with the group's options, and the first one that fails
+// for good, with a permanent error or after running out of attempts, cancels
+// the group's context so that the others stop retrying.
+//
+// Example:
+//
+//	g, ctx := ebo.NewGroup(ctx, ebo.API())
+//	g.Go(func() error { return fetchUser(ctx, id) })
+//	g.Go(func() error { return fetchOrders(ctx, id) })
+//	g.Go(func() error { return fetchInvoices(ctx, id) })
+//
+//	if err := g.Wait(); err != nil {
+//	    return err
+//	}
+type Group struct {
+	cancel context.CancelFunc
+	opts   []Option
+
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup returns a Group that retries every operation with opts, and a
+// context derived from ctx that is cancelled when an operation fails or
+// Wait returns. Operations should use the returned context, so that their
+// in-flight attempts stop too; the retry sleeps stop regardless.
+func NewGroup(ctx context.Context, opts ...Option) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	opts = append(opts[:len(opts):len(opts)], WithContext(ctx))
+	return &Group{cancel: cancel, opts: opts}, ctx
+}
+
+// Go retries fn in a new goroutine. If it fails for good, the group's
+// context is cancelled and, unless an earlier operation already failed,
+// its error is the one Wait returns.
+func (g *Group) Go(fn RetryableFunc) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		if err := Retry(fn, g.opts...); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// Wait blocks until all operations started with Go have returned, then
+// returns the error of the first one that failed, or nil if all succeeded.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
@@ -0,0 +1,83 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	t.Run("all succeed", func(t *testing.T) {
+		g, ctx := NewGroup(context.Background(), Initial(time.Millisecond), Tries(3))
+
+		var done atomic.Int32
+		for range 3 {
+			g.Go(func() error {
+				done.Add(1)
+				return nil
+			})
+		}
+
+		if err := g.Wait(); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+		if done.Load() != 3 {
+			t.Errorf("expected 3 operations, got %d", done.Load())
+		}
+		if ctx.Err() == nil {
+			t.Error("expected the group context to be cancelled after Wait")
+		}
+	})
+
+	t.Run("permanent error cancels the rest", func(t *testing.T) {
+		denied := errors.New("access denied")
+		g, ctx := NewGroup(context.Background(), Initial(5*time.Millisecond), Forever(),
+			WithPermanentDetector(func(err error) bool { return errors.Is(err, denied) }))
+
+		var retries atomic.Int32
+		for range 2 {
+			g.Go(func() error {
+				retries.Add(1)
+				return errors.New("backend unavailable")
+			})
+		}
+		g.Go(func() error {
+			time.Sleep(20 * time.Millisecond)
+			return denied
+		})
+
+		start := time.Now()
+		err := g.Wait()
+
+		if !errors.Is(err, denied) {
+			t.Errorf("expected %v, got %v", denied, err)
+		}
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			t.Errorf("expected the group context to be cancelled, got %v", ctx.Err())
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the other operations to stop, Wait took %v", elapsed)
+		}
+
+		// Nothing keeps retrying after Wait
+		n := retries.Load()
+		time.Sleep(20 * time.Millisecond)
+		if retries.Load() != n {
+			t.Error("expected no attempts after Wait returned")
+		}
+	})
+
+	t.Run("exhausted retries fail the group", func(t *testing.T) {
+		g, _ := NewGroup(context.Background(), Initial(time.Millisecond), Tries(2))
+		failure := errors.New("failure")
+
+		g.Go(func() error { return failure })
+		g.Go(func() error { return nil })
+
+		if err := g.Wait(); !errors.Is(err, failure) {
+			t.Errorf("expected %v, got %v", failure, err)
+		}
+	})
+}
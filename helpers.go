@@ -1,14 +1,19 @@
 package ebo
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"sync"
+	"time"
 )
 
 // RetryWithContext respects context cancellation during retries.
-// The retry will stop immediately if the context is cancelled.
+// It is an alias of RetryCtx, which is the preferred form.
 //
 // Example:
 //
@@ -19,14 +24,7 @@ import (
 //	    return performLongOperation()
 //	}, ebo.Tries(10), ebo.Initial(1*time.Second))
 func RetryWithContext(ctx context.Context, fn func() error, opts ...Option) error {
-	return Retry(func() error {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			return fn()
-		}
-	}, opts...)
+	return RetryCtx(ctx, fn, opts...)
 }
 
 // RetryWithLogging adds logging to track retry attempts.
@@ -71,17 +69,31 @@ func RetryWithLogging(fn func() error, logger *log.Logger, opts ...Option) error
 //	    return callAPI()
 //	}, isRetryable, ebo.Tries(3))
 func RetryWithCondition(fn func() error, condition func(error) bool, opts ...Option) error {
-	return Retry(func() error {
-		err := fn()
-		if err != nil && !condition(err) {
-			// Return a special error type that won't be retried
-			return &permanentError{err}
-		}
-		return err
+	return RetryWithConditionElapsed(fn, func(err error, _ time.Duration) bool {
+		return condition(err)
 	}, opts...)
 }
 
+// RetryWithConditionElapsed is RetryWithCondition with a condition that also
+// receives the time elapsed since the call started, for time-sensitive
+// policies independent of MaxTime.
+//
+// Example:
+//
+//	// Retry timeouts during the first 10s only, other errors until MaxTime
+//	err := ebo.RetryWithConditionElapsed(callAPI, func(err error, elapsed time.Duration) bool {
+//	    return !errors.Is(err, context.DeadlineExceeded) || elapsed < 10*time.Second
+//	}, ebo.MaxTime(time.Minute))
+func RetryWithConditionElapsed(fn func() error, condition func(err error, elapsed time.Duration) bool, opts ...Option) error {
+	start := time.Now()
+	opts = append(opts[:len(opts):len(opts)], WithClassifier(ConditionClassifier(func(err error) bool {
+		return condition(err, time.Since(start))
+	})))
+	return Retry(fn, opts...)
+}
+
 // permanentError wraps an error to indicate it should not be retried.
+// Retry loops stop on it and return the wrapped error, never the wrapper.
 type permanentError struct {
 	err error
 }
@@ -98,33 +110,155 @@ func (e *permanentError) Unwrap() error {
 type HTTPRetryTransport struct {
 	Transport http.RoundTripper
 	Options   []Option
+
+	// RetryBudget caps the retries shared by all requests sent through the
+	// transport (0 means unlimited). It is a token bucket holding up to
+	// RetryBudget retries: every retry takes one token, and every request
+	// returns RetryRatio of one. Once it is empty, requests get a single
+	// attempt, so during a long outage retries add at most RetryRatio to the
+	// upstream load rather than multiplying it by the number of tries.
+	RetryBudget int
+
+	// RetryRatio is the fraction of a retry each request adds back to
+	// RetryBudget (0 means 0.1, i.e. one retry per ten requests).
+	RetryRatio float64
+
+	mu    sync.Mutex
+	spent int64 // Thousandths of a retry taken from RetryBudget
+}
+
+// defaultRetryRatio is the RetryRatio used when none is set.
+const defaultRetryRatio = 0.1
+
+// Reset refills the transport's shared state, such as RetryBudget, to its
+// initial value. The budget refills on its own as requests succeed; Reset is
+// for operators who know the outage that drained it is over, e.g. after a
+// deploy or manual intervention upstream, and want retries back at once
+// without recreating the client. It is safe for concurrent use.
+func (t *HTTPRetryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spent = 0
 }
 
-// RoundTrip implements the http.RoundTripper interface
+// refill returns RetryRatio of a retry to RetryBudget for a new request.
+func (t *HTTPRetryTransport) refill() {
+	ratio := t.RetryRatio
+	if ratio <= 0 {
+		ratio = defaultRetryRatio
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spent = max(t.spent-int64(ratio*1000), 0)
+}
+
+// takeRetry spends one retry from RetryBudget, reporting false if none is left.
+// The retry loop calls it only once a retry is about to be slept for.
+func (t *HTTPRetryTransport) takeRetry() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.spent+1000 > int64(t.RetryBudget)*1000 {
+		return false
+	}
+	t.spent += 1000
+	return true
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+// A Retry-After header on a retryable response sets the wait before the next
+// attempt, capped at Max; HTTP-dates are read relative to the response's Date
+// header to tolerate clock skew.
+// The body of a retried response is drained (up to 256KB) and closed, so its
+// keep-alive connection can be reused by the next attempt.
+// Transport errors are retried only when IsRetryableNetErr (or the function
+// given to WithNetErrorClassifier) reports them as transient; others are
+// returned immediately.
+// Backoff respects the request context: sleeps are cut short on cancellation,
+// and when the context has a deadline, retrying stops once the next attempt
+// would not fit before it, returning the last error.
+// With AttemptTimeout, every attempt is sent with its own deadline, never
+// later than the request's.
+// When retries run out, the response is nil, as http.Client expects along
+// with an error. On a retryable status, the error is an *HTTPStatusError
+// whose Response is the last response, with its body already closed.
 func (t *HTTPRetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	transport := t.Transport
 	if transport == nil {
 		transport = http.DefaultTransport
 	}
 
+	config := newConfig(t.Options...)
+	config.seedFor(req)
+	checker := config.responseChecker()
+	retryable := config.netErrRetryable()
+
+	ctx := req.Context()
+	if config.ctx == nil {
+		config.ctx = ctx
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		config.deadline = deadline
+	}
+	if t.RetryBudget > 0 {
+		t.refill()
+		config.takeRetry = t.takeRetry
+	}
+
 	var resp *http.Response
-	err := Retry(func() error {
-		r, err := transport.RoundTrip(req)
+	err := retry(config, func() error {
+		r, err := roundTripWithin(transport, req, config.attemptTimeout)
 		if err != nil {
+			// An attempt that ran out of its own time is retried while the request is live
+			timedOut := config.attemptTimeout > 0 && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil
+			if !timedOut && !retryable(err) {
+				return &permanentError{err}
+			}
 			return err
 		}
 		resp = r
 
 		// Check if the status code is retryable
-		if r.StatusCode >= 500 || r.StatusCode == 429 {
-			_ = r.Body.Close()
-			return fmt.Errorf("retryable status: %d", r.StatusCode)
+		if decision := config.decide(checker, r); decision.Retry {
+			drainBody(r.Body)
+			return retryableStatus(r, decision.After)
 		}
 
 		return nil
-	}, t.Options...)
+	})
 
-	return resp, err
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// roundTripWithin sends req through transport, bounded by timeout when it is
+// positive. The attempt deadline is released when the response body is closed.
+func roundTripWithin(transport http.RoundTripper, req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return transport.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the context of its attempt when closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 // NewHTTPClient creates an HTTP client with retry capabilities.
@@ -153,6 +287,13 @@ type RetryableHTTPFunc func(*http.Request) (*http.Response, error)
 
 // HTTPDo wraps an HTTP request with retry logic.
 // It will retry the request based on the response status code and the provided options.
+// Like HTTPRetryTransport, it waits as long as a Retry-After header asks, capped at Max.
+//
+// When retries are exhausted on a retryable status, HTTPDo returns the last
+// response together with an *HTTPStatusError. Its body is still readable (for
+// example to log the upstream error payload): bodies up to 256KB are buffered,
+// larger ones are left open. The caller must close the body of any non-nil
+// response, including this one.
 //
 // Example:
 //
@@ -160,31 +301,118 @@ type RetryableHTTPFunc func(*http.Request) (*http.Response, error)
 //	req.Header.Set("Content-Type", "application/json")
 //
 //	resp, err := ebo.HTTPDo(req, nil, ebo.API())
+//	if resp != nil {
+//	    defer resp.Body.Close()
+//	}
 //	if err != nil {
-//	    log.Fatal(err)
+//	    if resp != nil {
+//	        payload, _ := io.ReadAll(resp.Body)
+//	        log.Printf("upstream failed: %v: %s", err, payload)
+//	    }
+//	    return err
 //	}
-//	defer resp.Body.Close()
 func HTTPDo(req *http.Request, client *http.Client, opts ...Option) (*http.Response, error) {
+	return httpDo(req, client, newConfig(opts...), false)
+}
+
+// HTTPDoCtx is HTTPDo with a retry-scoped context: ctx bounds the whole retry
+// loop and interrupts the backoff sleeps, while every attempt sends a clone of
+// req made with req.Clone(ctx). Per-attempt timeouts can then be applied by
+// the client without cutting the retries short.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//
+//	client := &http.Client{Timeout: 5 * time.Second} // Per attempt
+//	resp, err := ebo.HTTPDoCtx(ctx, req, client, ebo.API())
+func HTTPDoCtx(ctx context.Context, req *http.Request, client *http.Client, opts ...Option) (*http.Response, error) {
+	return httpDo(req, client, newConfig(append(opts[:len(opts):len(opts)], WithContext(ctx))...), true)
+}
+
+// httpDo runs the retry loop of HTTPDo. With clone, every attempt sends a
+// clone of req carrying the retry loop's context.
+func httpDo(req *http.Request, client *http.Client, config *RetryConfig, clone bool) (*http.Response, error) {
 	if client == nil {
 		client = http.DefaultClient
 	}
 
+	config.seedFor(req)
+	checker := config.responseChecker()
+
 	var resp *http.Response
-	err := Retry(func() error {
-		r, err := client.Do(req)
+
+	// Release the response kept for the caller once a retry is decided,
+	// draining an unbuffered body so its connection is reused during the backoff
+	onRetry := config.onRetry
+	config.onRetry = func(attempt int, delay, jitter time.Duration) {
+		if resp != nil {
+			drainBody(resp.Body)
+			resp = nil
+		}
+		if onRetry != nil {
+			onRetry(attempt, delay, jitter)
+		}
+	}
+
+	err := retry(config, func() error {
+		attempt := req
+		if clone {
+			attempt = req.Clone(config.context())
+		}
+		r, err := client.Do(attempt)
 		if err != nil {
+			resp = nil
 			return err
 		}
 
 		// Check if the status code is retryable
-		if r.StatusCode >= 500 || r.StatusCode == 429 {
-			_ = r.Body.Close()
-			return fmt.Errorf("retryable status: %d", r.StatusCode)
+		if decision := config.decide(checker, r); decision.Retry {
+			// Keep the body readable in case this turns out to be the last attempt
+			if err := bufferBody(r); err != nil {
+				resp = nil
+				return err
+			}
+			resp = r
+			return retryableStatus(r, decision.After)
 		}
 
 		resp = r
 		return nil
-	}, opts...)
+	})
 
 	return resp, err
 }
+
+// maxDrainBytes caps how much of a discarded response body drainBody reads.
+// Larger bodies are abandoned along with their connection.
+const maxDrainBytes = 256 << 10
+
+// drainBody reads up to maxDrainBytes of body and closes it, so that the
+// connection can go back to the pool for the next attempt.
+func drainBody(body io.ReadCloser) {
+	_, _ = io.CopyN(io.Discard, body, maxDrainBytes)
+	_ = body.Close()
+}
+
+// bufferBody reads and closes the body of r, replacing it with an in-memory
+// copy. A body larger than maxDrainBytes is not buffered: what was read is put
+// back in front of the rest, which keeps its connection until it is closed.
+func bufferBody(r *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxDrainBytes+1))
+	if err != nil {
+		_ = r.Body.Close()
+		return err
+	}
+	if len(body) > maxDrainBytes {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
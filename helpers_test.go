@@ -7,9 +7,13 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -135,6 +139,39 @@ func TestRetryWithCondition(t *testing.T) {
 	})
 }
 
+func TestRetryWithConditionElapsed(t *testing.T) {
+	const threshold = 20 * time.Millisecond
+	temporary := errors.New("temporary error")
+
+	attempts := 0
+	var seen []time.Duration
+	start := time.Now()
+	err := RetryWithConditionElapsed(func() error {
+		attempts++
+		return temporary
+	}, func(err error, elapsed time.Duration) bool {
+		seen = append(seen, elapsed)
+		return elapsed < threshold
+	}, Initial(5*time.Millisecond), Linear(), NoJitter(), Tries(100))
+	took := time.Since(start)
+
+	if !errors.Is(err, temporary) {
+		t.Errorf("expected the last error, got %v", err)
+	}
+	if attempts < 2 || attempts >= 100 {
+		t.Errorf("expected retrying to stop at the threshold, got %d attempts", attempts)
+	}
+	if took < threshold {
+		t.Errorf("expected to retry for at least %v, took %v", threshold, took)
+	}
+	if last := seen[len(seen)-1]; last < threshold {
+		t.Errorf("expected the condition to stop at elapsed >= %v, got %v", threshold, last)
+	}
+	if !slices.IsSorted(seen) {
+		t.Errorf("expected increasing elapsed times, got %v", seen)
+	}
+}
+
 func TestHTTPRetryTransport(t *testing.T) {
 	attempts := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -177,6 +214,395 @@ func TestHTTPRetryTransport(t *testing.T) {
 	}
 }
 
+func TestHTTPRetryTransportDeadline(t *testing.T) {
+	start := time.Now()
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		// Upstream recovers only after the caller's deadline
+		if time.Since(start) < time.Second {
+			time.Sleep(10 * time.Millisecond)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &HTTPRetryTransport{
+			Options: []Option{
+				Initial(60 * time.Millisecond),
+				Multiplier(2.0),
+				Tries(10),
+				NoJitter(),
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	resp, err := client.Do(req)
+	elapsed := time.Since(start)
+	if resp != nil {
+		_ = resp.Body.Close()
+	}
+
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected last upstream error, got deadline error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "retryable status: 503") {
+		t.Errorf("expected retryable status error, got: %v", err)
+	}
+	if elapsed > 150*time.Millisecond {
+		t.Errorf("expected to give up before the 150ms deadline, took %v", elapsed)
+	}
+	// 0ms, 60ms and a trimmed sleep before the last attempt
+	if n := atomic.LoadInt32(&attempts); n < 2 || n > 3 {
+		t.Errorf("expected 2-3 attempts, got %d", n)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestAttemptTimeout(t *testing.T) {
+	// The server answers with status when it is set, and hangs until the attempt gives up otherwise
+	var status atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := status.Swap(0)
+		if code == 0 {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(int(code))
+	}))
+	defer server.Close()
+
+	var deadlines []time.Time
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		deadline, _ := req.Context().Deadline()
+		deadlines = append(deadlines, deadline)
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	t.Run("shortens attempts near the deadline", func(t *testing.T) {
+		status.Store(http.StatusServiceUnavailable)
+		deadlines = nil
+		client := &http.Client{Transport: &HTTPRetryTransport{
+			Transport: transport,
+			Options:   []Option{Initial(300 * time.Millisecond), Max(2 * time.Second), NoJitter(), Tries(10), AttemptTimeout(300 * time.Millisecond)},
+		}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		deadline, _ := ctx.Deadline()
+		req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		elapsed := time.Since(start)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected a deadline error, got %v", err)
+		}
+		if elapsed > 600*time.Millisecond {
+			t.Errorf("expected to stop by the 500ms deadline, took %v", elapsed)
+		}
+		// A quick 503, then a hanging attempt from 300ms that only gets the 200ms left
+		if len(deadlines) != 2 {
+			t.Fatalf("expected 2 attempts, got %d", len(deadlines))
+		}
+		if !deadlines[0].Before(deadline) {
+			t.Errorf("expected the first attempt to end before the request deadline, got %v", deadlines[0].Sub(deadline))
+		}
+		if !deadlines[1].Equal(deadline) {
+			t.Errorf("expected the second attempt to end at the request deadline, got %v", deadlines[1].Sub(deadline))
+		}
+	})
+
+	t.Run("retries a timed out attempt", func(t *testing.T) {
+		status.Store(0)
+		deadlines = nil
+		client := &http.Client{Transport: &HTTPRetryTransport{
+			Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				resp, err := transport(req)
+				status.Store(http.StatusOK)
+				return resp, err
+			}),
+			Options: []Option{Initial(time.Millisecond), Tries(3), AttemptTimeout(50 * time.Millisecond)},
+		}}
+
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || len(deadlines) != 2 {
+			t.Errorf("expected 200 on the second attempt, got %d after %d attempts", resp.StatusCode, len(deadlines))
+		}
+		if _, err := io.ReadAll(resp.Body); err != nil {
+			t.Errorf("expected the body to outlive the attempt, got %v", err)
+		}
+	})
+}
+
+func TestHTTPRetryTransportReset(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	transport := &HTTPRetryTransport{
+		Options:     []Option{Initial(time.Millisecond), Tries(3)},
+		RetryBudget: 2,
+	}
+	client := &http.Client{Transport: transport}
+
+	get := func() int32 {
+		t.Helper()
+		atomic.StoreInt32(&hits, 0)
+		resp, err := client.Get(server.URL)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		if err == nil || !strings.Contains(err.Error(), "retryable status: 503") {
+			t.Errorf("expected retryable status error, got %v", err)
+		}
+		return atomic.LoadInt32(&hits)
+	}
+
+	if n := get(); n != 3 {
+		t.Errorf("expected 3 attempts while the budget lasts, got %d", n)
+	}
+	if n := get(); n != 1 {
+		t.Errorf("expected 1 attempt with the budget drained, got %d", n)
+	}
+
+	transport.Reset()
+
+	if n := get(); n != 3 {
+		t.Errorf("expected 3 attempts after Reset, got %d", n)
+	}
+}
+
+func TestHTTPRetryTransportBudgetSpent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var retries int32
+	client := &http.Client{Transport: &HTTPRetryTransport{
+		Options: []Option{Initial(100 * time.Millisecond), NoJitter(), Tries(3), OnRetry(func(int, time.Duration, time.Duration) {
+			atomic.AddInt32(&retries, 1)
+		})},
+		RetryBudget: 1,
+	}}
+
+	get := func() time.Duration {
+		t.Helper()
+		start := time.Now()
+		resp, err := client.Get(server.URL)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		if err == nil || !strings.Contains(err.Error(), "retryable status: 503") {
+			t.Errorf("expected retryable status error, got %v", err)
+		}
+		return time.Since(start)
+	}
+
+	// The budget pays for one retry; the next failure gives up without sleeping
+	if elapsed := get(); elapsed < 100*time.Millisecond || elapsed >= 200*time.Millisecond {
+		t.Errorf("expected one 100ms backoff, took %v", elapsed)
+	}
+	if elapsed := get(); elapsed >= 50*time.Millisecond {
+		t.Errorf("expected a spent budget to give up without a backoff, took %v", elapsed)
+	}
+	if n := atomic.LoadInt32(&retries); n != 1 {
+		t.Errorf("expected OnRetry once, got %d", n)
+	}
+}
+
+func TestHTTPRetryTransportBudgetRefills(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	get := func(client *http.Client) int32 {
+		t.Helper()
+		atomic.StoreInt32(&hits, 0)
+		resp, err := client.Get(server.URL)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		if err == nil || !strings.Contains(err.Error(), "retryable status: 503") {
+			t.Errorf("expected retryable status error, got %v", err)
+		}
+		return atomic.LoadInt32(&hits)
+	}
+
+	t.Run("requests add back RetryRatio", func(t *testing.T) {
+		client := &http.Client{Transport: &HTTPRetryTransport{
+			Options:     []Option{Initial(time.Millisecond), Tries(2)},
+			RetryBudget: 1,
+			RetryRatio:  0.5,
+		}}
+
+		want := []int32{2, 1, 2, 1}
+		for i, w := range want {
+			if n := get(client); n != w {
+				t.Errorf("request %d: expected %d attempts, got %d", i+1, w, n)
+			}
+		}
+	})
+
+	t.Run("no token without a retry", func(t *testing.T) {
+		transport := &HTTPRetryTransport{
+			Options:     []Option{Initial(time.Millisecond), Tries(3), MaxTime(time.Nanosecond)},
+			RetryBudget: 1,
+		}
+		client := &http.Client{Transport: transport}
+
+		// Attempts that end the loop, here on MaxTime, leave the budget alone
+		for range 3 {
+			if n := get(client); n != 1 {
+				t.Errorf("expected 1 attempt within MaxTime, got %d", n)
+			}
+		}
+		transport.Options = []Option{Initial(time.Millisecond), Tries(2)}
+		if n := get(client); n != 2 {
+			t.Errorf("expected the budget to still pay for a retry, got %d attempts", n)
+		}
+	})
+}
+
+// countingTransport counts round trips before delegating to http.DefaultTransport.
+type countingTransport struct {
+	calls int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestHTTPRetryTransportNetErrors(t *testing.T) {
+	// Reserve an address that refuses connections until the server starts
+	reserved, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := reserved.Addr().String()
+	_ = reserved.Close()
+
+	t.Run("retries refused connection", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("up"))
+		}))
+		defer server.Close()
+		started := false
+
+		counter := &countingTransport{}
+		client := &http.Client{Transport: &HTTPRetryTransport{
+			Transport: counter,
+			Options: []Option{
+				Initial(10 * time.Millisecond),
+				Tries(3),
+				// Bring the server up during the first backoff
+				WithSleepHook(func() {
+					if started {
+						return
+					}
+					l, err := net.Listen("tcp", addr)
+					if err != nil {
+						t.Errorf("failed to listen on %s: %v", addr, err)
+						return
+					}
+					_ = server.Listener.Close()
+					server.Listener = l
+					server.Start()
+					started = true
+				}, nil),
+			},
+		}}
+
+		resp, err := client.Get("http://" + addr)
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if n := atomic.LoadInt32(&counter.calls); n != 2 {
+			t.Errorf("expected 2 attempts, got %d", n)
+		}
+	})
+
+	t.Run("fatal error is not retried", func(t *testing.T) {
+		counter := &countingTransport{}
+		client := &http.Client{Transport: &HTTPRetryTransport{
+			Transport: counter,
+			Options:   []Option{Initial(time.Second), Tries(3)},
+		}}
+
+		req, _ := http.NewRequest("GET", "ftp://example.com/file", nil)
+		_, err := client.Do(req)
+		if err == nil || !strings.Contains(err.Error(), "unsupported protocol scheme") {
+			t.Errorf("expected unsupported protocol scheme error, got %v", err)
+		}
+		if n := atomic.LoadInt32(&counter.calls); n != 1 {
+			t.Errorf("expected 1 attempt, got %d", n)
+		}
+	})
+
+	t.Run("classifier override", func(t *testing.T) {
+		reserved, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		closed := reserved.Addr().String()
+		_ = reserved.Close()
+
+		counter := &countingTransport{}
+		client := &http.Client{Transport: &HTTPRetryTransport{
+			Transport: counter,
+			Options: []Option{
+				Initial(time.Millisecond),
+				Tries(3),
+				WithNetErrorClassifier(func(error) bool { return false }),
+			},
+		}}
+
+		if _, err := client.Get("http://" + closed); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if n := atomic.LoadInt32(&counter.calls); n != 1 {
+			t.Errorf("expected 1 attempt, got %d", n)
+		}
+	})
+}
+
 func TestNewHTTPClient(t *testing.T) {
 	attempts := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -241,3 +667,628 @@ func TestHTTPDo(t *testing.T) {
 		t.Errorf("expected 2 attempts, got %d", attempts)
 	}
 }
+
+func TestHTTPDoLargeBody(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = fmt.Fprintf(w, "attempt %d ", n)
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 4*maxDrainBytes))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := HTTPDo(req, nil, Initial(time.Millisecond), Tries(3))
+	if resp == nil {
+		t.Fatalf("expected the last response, got %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("expected readable body, got error: %v", err)
+	}
+	if want := len("attempt 3 ") + 4*maxDrainBytes; len(body) != want || !bytes.HasPrefix(body, []byte("attempt 3 ")) {
+		t.Errorf("expected the full %d byte body of attempt 3, got %d bytes starting %q", want, len(body), body[:min(len(body), 10)])
+	}
+}
+
+func TestHTTPDoExhausted(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = fmt.Fprintf(w, "upstream down (attempt %d)", attempts)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	resp, err := HTTPDo(req, nil, Initial(time.Millisecond), Tries(3))
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "retryable status: 502") {
+		t.Errorf("expected retryable status error, got: %v", err)
+	}
+	if resp == nil {
+		t.Fatal("expected the last response, got nil")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("expected readable body, got error: %v", err)
+	}
+	if string(body) != "upstream down (attempt 3)" {
+		t.Errorf("expected body of the last attempt, got %q", body)
+	}
+}
+
+func TestRetryOnJSONField(t *testing.T) {
+	newServer := func(attempts *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			if atomic.AddInt32(attempts, 1) <= 2 {
+				_, _ = io.WriteString(w, `{"error": {"code": "RATE_LIMITED"}}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"data": "ok"}`)
+		}))
+	}
+
+	t.Run("HTTPDo", func(t *testing.T) {
+		var attempts int32
+		server := newServer(&attempts)
+		defer server.Close()
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := HTTPDo(req, nil, Initial(time.Millisecond), Tries(5), RetryOnJSONField("error.code", "RATE_LIMITED"))
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != `{"data": "ok"}` {
+			t.Errorf("expected final body to be readable, got %q", body)
+		}
+		if n := atomic.LoadInt32(&attempts); n != 3 {
+			t.Errorf("expected 3 attempts, got %d", n)
+		}
+	})
+
+	t.Run("transport", func(t *testing.T) {
+		var attempts int32
+		server := newServer(&attempts)
+		defer server.Close()
+
+		client := NewHTTPClient(Initial(time.Millisecond), Tries(5), RetryOnJSONField("error.code", "RATE_LIMITED"))
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != `{"data": "ok"}` {
+			t.Errorf("expected final body to be readable, got %q", body)
+		}
+		if n := atomic.LoadInt32(&attempts); n != 3 {
+			t.Errorf("expected 3 attempts, got %d", n)
+		}
+	})
+
+	t.Run("exhausted keeps payload", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"error_code": 429}`)
+		}))
+		defer server.Close()
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := HTTPDo(req, nil, Initial(time.Millisecond), Tries(2), RetryOnJSONField("error_code", "429"))
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != `{"error_code": 429}` {
+			t.Errorf("expected last payload, got %q", body)
+		}
+	})
+
+	t.Run("skips other responses", func(t *testing.T) {
+		large := `{"error_code": 429, "padding": "` + strings.Repeat("x", maxDrainBytes) + `"}`
+		tests := []struct {
+			name        string
+			contentType string
+			body        string
+		}{
+			{"not JSON", "text/plain", `{"error_code": 429}`},
+			{"too large", "application/json", large},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var attempts int32
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&attempts, 1)
+					w.Header().Set("Content-Type", tt.contentType)
+					_, _ = io.WriteString(w, tt.body)
+				}))
+				defer server.Close()
+
+				req, _ := http.NewRequest("GET", server.URL, nil)
+				resp, err := HTTPDo(req, nil, Initial(time.Millisecond), Tries(2), RetryOnJSONField("error_code", "429"))
+				if err != nil {
+					t.Fatalf("expected success, got error: %v", err)
+				}
+				defer func() { _ = resp.Body.Close() }()
+
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.body || atomic.LoadInt32(&attempts) != 1 {
+					t.Errorf("expected the full body after 1 attempt, got %d bytes after %d", len(body), attempts)
+				}
+			})
+		}
+	})
+}
+
+func TestHTTPDoRetryAfterChecker(t *testing.T) {
+	t.Run("429 with Retry-After is retried", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 2 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		resp, err := HTTPDo(req, nil, Initial(10*time.Millisecond), Tries(3), WithChecker(RetryAfterChecker))
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("429 without Retry-After stops", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		req, err := http.NewRequest("GET", server.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		resp, err := HTTPDo(req, nil, Initial(10*time.Millisecond), Tries(3), WithChecker(RetryAfterChecker))
+		if err != nil {
+			t.Fatalf("expected response, got error: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("expected status 429, got %d", resp.StatusCode)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+	})
+}
+
+func TestWithDelayChecker(t *testing.T) {
+	newServer := func(calls *[]time.Time) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, time.Now())
+			if len(*calls) < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	checker := func(after time.Duration) CheckerWithDelay {
+		return func(resp *http.Response) RetryDecision {
+			return RetryDecision{Retry: resp.StatusCode >= 500, After: after}
+		}
+	}
+
+	t.Run("HTTPDo honors the delay", func(t *testing.T) {
+		var calls []time.Time
+		server := newServer(&calls)
+		defer server.Close()
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := HTTPDo(req, nil, Initial(time.Millisecond), NoJitter(), Tries(3), WithDelayChecker(checker(60*time.Millisecond)))
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if len(calls) != 2 {
+			t.Fatalf("expected 2 attempts, got %d", len(calls))
+		}
+		if gap := calls[1].Sub(calls[0]); gap < 60*time.Millisecond {
+			t.Errorf("expected to wait at least 60ms, waited %v", gap)
+		}
+	})
+
+	t.Run("transport clamps the delay to Max", func(t *testing.T) {
+		var calls []time.Time
+		server := newServer(&calls)
+		defer server.Close()
+
+		client := NewHTTPClient(Initial(time.Millisecond), Max(20*time.Millisecond), NoJitter(), Tries(3), WithDelayChecker(checker(time.Minute)))
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if len(calls) != 2 {
+			t.Fatalf("expected 2 attempts, got %d", len(calls))
+		}
+		if gap := calls[1].Sub(calls[0]); gap < 20*time.Millisecond || gap > 500*time.Millisecond {
+			t.Errorf("expected to wait about 20ms, waited %v", gap)
+		}
+	})
+
+	t.Run("replaces the response checker", func(t *testing.T) {
+		var calls []time.Time
+		server := newServer(&calls)
+		defer server.Close()
+
+		never := func(*http.Response) RetryDecision { return RetryDecision{} }
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := HTTPDo(req, nil, Initial(time.Millisecond), Tries(3), WithChecker(DefaultResponseChecker), WithDelayChecker(never))
+		if err != nil {
+			t.Fatalf("expected the 503 to be returned without error, got %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusServiceUnavailable || len(calls) != 1 {
+			t.Errorf("expected a single 503 attempt, got %d after %d attempts", resp.StatusCode, len(calls))
+		}
+	})
+}
+
+func TestJitterSeedFunc(t *testing.T) {
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	}
+	serverA, serverB := newServer(), newServer()
+	defer serverA.Close()
+	defer serverB.Close()
+
+	byHost := JitterSeedFunc(func(req *http.Request) int64 {
+		var seed int64
+		for _, b := range []byte(req.URL.Host) {
+			seed = seed*31 + int64(b)
+		}
+		return seed
+	})
+
+	delays := func(url string) []time.Duration {
+		logger := &delayLogger{}
+		client := NewHTTPClient(Initial(time.Millisecond), Max(50*time.Millisecond), Jitter(0.9), Tries(5), WithLogger(logger), byHost)
+		if resp, err := client.Get(url); err == nil {
+			_ = resp.Body.Close()
+		}
+		return logger.delays
+	}
+
+	first := delays(serverA.URL)
+	if len(first) != 4 {
+		t.Fatalf("expected 4 delays, got %v", first)
+	}
+	if again := delays(serverA.URL); !slices.Equal(first, again) {
+		t.Errorf("expected the same delays for the same host, got %v and %v", first, again)
+	}
+	if other := delays(serverB.URL); slices.Equal(first, other) {
+		t.Errorf("expected different delays for another host, got %v for both", other)
+	}
+}
+
+func TestRetryableResponsesReuseConnections(t *testing.T) {
+	newServer := func(conns *atomic.Int32) *httptest.Server {
+		attempts := 0
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts%3 != 0 {
+				// An error page too large to be discarded by closing the body alone
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write(bytes.Repeat([]byte("x"), 300<<10))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				conns.Add(1)
+			}
+		}
+		server.Start()
+		return server
+	}
+	opts := []Option{Initial(time.Millisecond), Tries(3)}
+
+	t.Run("transport", func(t *testing.T) {
+		var conns atomic.Int32
+		server := newServer(&conns)
+		defer server.Close()
+
+		transport := &http.Transport{}
+		defer transport.CloseIdleConnections()
+		client := &http.Client{Transport: &HTTPRetryTransport{Transport: transport, Options: opts}}
+
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if n := conns.Load(); n != 1 {
+			t.Errorf("expected all 3 attempts on 1 connection, got %d connections", n)
+		}
+	})
+
+	t.Run("HTTPDo", func(t *testing.T) {
+		var conns atomic.Int32
+		server := newServer(&conns)
+		defer server.Close()
+
+		transport := &http.Transport{}
+		defer transport.CloseIdleConnections()
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := HTTPDo(req, &http.Client{Transport: transport}, opts...)
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if n := conns.Load(); n != 1 {
+			t.Errorf("expected all 3 attempts on 1 connection, got %d connections", n)
+		}
+	})
+}
+
+func TestStatusBackoff(t *testing.T) {
+	overrides := map[int]Option{
+		http.StatusTooManyRequests:    Initial(40 * time.Millisecond),
+		http.StatusServiceUnavailable: Initial(2 * time.Millisecond),
+	}
+
+	tests := []struct {
+		status   int
+		expected time.Duration
+	}{
+		{http.StatusTooManyRequests, 40 * time.Millisecond},
+		{http.StatusServiceUnavailable, 2 * time.Millisecond},
+		{http.StatusBadGateway, time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			var delays []time.Duration
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			resp, err := HTTPDo(req, nil,
+				Initial(time.Millisecond), NoJitter(), Tries(3),
+				WithChecker(func(resp *http.Response) bool {
+					return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+				}),
+				StatusBackoff(overrides),
+				OnRetry(func(_ int, delay, _ time.Duration) {
+					delays = append(delays, delay)
+				}),
+			)
+			if err != nil {
+				t.Fatalf("expected success, got error: %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if len(delays) != 1 || delays[0] != tt.expected {
+				t.Errorf("expected a single %v delay, got %v", tt.expected, delays)
+			}
+		})
+	}
+
+	t.Run("derived settings", func(t *testing.T) {
+		opts := []Option{Initial(10 * time.Millisecond), Max(90 * time.Millisecond), NoJitter()}
+		config := newConfig(append(opts, StatusBackoff(map[int]Option{
+			http.StatusServiceUnavailable: ReachMaxBy(3),
+		}))...)
+		direct := newConfig(append(opts, ReachMaxBy(3))...)
+
+		err := &HTTPStatusError{StatusCode: http.StatusServiceUnavailable}
+		for attempt := 2; attempt <= 4; attempt++ {
+			got, _ := config.statusDelay(err, attempt)
+			if want := direct.delay(attempt); got != want {
+				t.Errorf("attempt %d: expected %v as with ReachMaxBy passed directly, got %v", attempt, want, got)
+			}
+		}
+	})
+}
+
+func TestHTTPStatusError(t *testing.T) {
+	newServer := func() *httptest.Server {
+		calls := 0
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("upstream down"))
+		}))
+	}
+
+	t.Run("HTTPDo", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := HTTPDo(req, nil, Initial(time.Millisecond), Tries(2))
+		if resp == nil {
+			t.Fatal("expected the last response")
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		var statusErr *HTTPStatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("expected an *HTTPStatusError, got %v", err)
+		}
+		if statusErr.StatusCode != http.StatusBadGateway || statusErr.Status != "502 Bad Gateway" {
+			t.Errorf("expected the last status 502, got %d %q", statusErr.StatusCode, statusErr.Status)
+		}
+		if statusErr.Response != resp {
+			t.Error("expected the error to carry the returned response")
+		}
+		if body, _ := io.ReadAll(statusErr.Response.Body); string(body) != "upstream down" {
+			t.Errorf("expected a readable body, got %q", body)
+		}
+	})
+
+	t.Run("transport", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		// http.Client logs when a RoundTripper returns a response with an error
+		var logs bytes.Buffer
+		log.SetOutput(&logs)
+		defer log.SetOutput(os.Stderr)
+
+		client := NewHTTPClient(Initial(time.Millisecond), Tries(2))
+		resp, err := client.Get(server.URL)
+		if resp != nil {
+			_ = resp.Body.Close()
+			t.Error("expected no response alongside the error")
+		}
+
+		var statusErr *HTTPStatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("expected an *HTTPStatusError, got %v", err)
+		}
+		if statusErr.StatusCode != http.StatusBadGateway || statusErr.Response.StatusCode != http.StatusBadGateway {
+			t.Errorf("expected the last status 502, got %d", statusErr.StatusCode)
+		}
+		if logs.Len() > 0 {
+			t.Errorf("expected http.Client to log nothing, got %q", logs.String())
+		}
+	})
+
+	t.Run("RoundTrip returns the response in the error only", func(t *testing.T) {
+		server := newServer()
+		defer server.Close()
+
+		transport := &HTTPRetryTransport{Options: []Option{Initial(time.Millisecond), Tries(2)}}
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := transport.RoundTrip(req)
+		if resp != nil {
+			_ = resp.Body.Close()
+			t.Error("expected no response alongside the error")
+		}
+
+		var statusErr *HTTPStatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("expected an *HTTPStatusError, got %v", err)
+		}
+		last := statusErr.Response
+		if last == nil || last.StatusCode != http.StatusBadGateway || statusErr.Status != last.Status {
+			t.Errorf("expected the last 502 response in the error, got %+v", statusErr)
+		}
+	})
+}
+
+func TestHTTPDoCtx(t *testing.T) {
+	t.Run("cancel during backoff", func(t *testing.T) {
+		calls := int32(0)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		start := time.Now()
+		resp, err := HTTPDoCtx(ctx, req, nil, Initial(time.Hour), Tries(3), OnRetry(func(int, time.Duration, time.Duration) {
+			cancel()
+		}))
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 attempt, got %d", calls)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the backoff to be interrupted, took %v", elapsed)
+		}
+	})
+
+	t.Run("attempts use the retry context", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		// The request's own context is already done, but only ctx applies
+		reqCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, _ := http.NewRequestWithContext(reqCtx, "GET", server.URL, nil)
+
+		resp, err := HTTPDoCtx(context.Background(), req, nil, Initial(time.Millisecond), Tries(2))
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		_ = resp.Body.Close()
+	})
+}
@@ -1,12 +1,25 @@
 package ebo
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // RetryMiddleware creates HTTP middleware that automatically retries requests
 // based on configurable conditions. It wraps an existing http.Handler.
+// A panic in the handler is recovered and retried as a 500 response.
+// Retries, including the sleeps between them, stop once the request context is done.
 type RetryMiddleware struct {
 	next    http.Handler
 	options []Option
@@ -16,11 +29,263 @@ type RetryMiddleware struct {
 // ResponseChecker is a function that determines if a response should trigger a retry
 type ResponseChecker func(*http.Response) bool
 
+// RetryDecision is the verdict of a CheckerWithDelay on a response.
+type RetryDecision struct {
+	Retry bool          // Whether the response is retried
+	After time.Duration // Wait before the next attempt, capped at Max (0 uses the normal backoff)
+}
+
+// CheckerWithDelay is a ResponseChecker that can also choose the wait before
+// the next attempt, keeping the decision and its timing in one place.
+// See WithDelayChecker.
+type CheckerWithDelay func(*http.Response) RetryDecision
+
 // DefaultResponseChecker returns true for 5xx errors and 429 (Too Many Requests)
 func DefaultResponseChecker(resp *http.Response) bool {
 	return resp.StatusCode >= 500 || resp.StatusCode == 429
 }
 
+// RetryAfterChecker returns true for 5xx errors and for 429 (Too Many Requests)
+// responses that carry a parseable Retry-After header. A 429 without a usable
+// Retry-After is treated as final, for APIs that expect clients to stop instead
+// of retrying blindly.
+func RetryAfterChecker(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		_, ok := retryAfter(resp)
+		return ok
+	}
+	return resp.StatusCode >= 500
+}
+
+// CheckHeader returns a ResponseChecker that retries when the response header
+// name has exactly the given value, regardless of the status code. Useful for
+// APIs that signal "retry later" through headers on 200 responses.
+//
+// Example:
+//
+//	checker := ebo.CheckHeader("X-RateLimit-Remaining", "0")
+//	mw := ebo.Middleware(checker, ebo.API())
+func CheckHeader(name, value string) ResponseChecker {
+	return func(resp *http.Response) bool {
+		return resp.Header.Get(name) == value
+	}
+}
+
+// AnyChecker combines checkers into one that retries when any of them returns
+// true. Checkers run in order and evaluation stops at the first true result.
+// Nil checkers are skipped.
+//
+// Example:
+//
+//	// Default behavior plus retries on 404 and 409
+//	checker := ebo.AnyChecker(ebo.DefaultResponseChecker, func(resp *http.Response) bool {
+//	    return resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusConflict
+//	})
+//	r.Use(ebo.Middleware(checker, ebo.API()))
+func AnyChecker(checkers ...ResponseChecker) ResponseChecker {
+	return func(resp *http.Response) bool {
+		for _, checker := range checkers {
+			if checker != nil && checker(resp) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// jsonFieldChecker returns a ResponseChecker that retries when the JSON body
+// has one of values at the dotted path. Only application/json responses are
+// inspected, reading at most maxDrainBytes of the body, which is then put
+// back, so it stays readable for the caller. Bodies that are not JSON
+// objects, are larger or lack the field are not retried.
+func jsonFieldChecker(path string, values ...string) ResponseChecker {
+	keys := strings.Split(path, ".")
+
+	return func(resp *http.Response) bool {
+		if resp.Body == nil || resp.Body == http.NoBody {
+			return false
+		}
+		if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			return false
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxDrainBytes+1))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		if err != nil || len(body) > maxDrainBytes {
+			return false
+		}
+
+		var field any
+		if json.Unmarshal(body, &field) != nil {
+			return false
+		}
+		for _, key := range keys {
+			object, ok := field.(map[string]any)
+			if !ok {
+				return false
+			}
+			if field, ok = object[key]; !ok {
+				return false
+			}
+		}
+
+		got := fmt.Sprint(field)
+		for _, value := range values {
+			if got == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// retryAfter returns the wait requested by the Retry-After header of resp.
+// An HTTP-date is interpreted against the response's Date header when present,
+// so clock skew between client and server does not distort the wait.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	now := time.Now()
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		now = date
+	}
+	return ParseRetryAfter(resp.Header.Get("Retry-After"), now)
+}
+
+// HTTPStatusError is the error HTTPDo and HTTPRetryTransport return when
+// retries run out on a retryable status. Use errors.As to get the status code
+// and the response it came from.
+//
+// Example:
+//
+//	resp, err := ebo.HTTPDo(req, nil, ebo.API())
+//	var statusErr *ebo.HTTPStatusError
+//	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests {
+//	    return ErrRateLimited
+//	}
+type HTTPStatusError struct {
+	StatusCode int    // e.g. 503
+	Status     string // e.g. "503 Service Unavailable"
+
+	// Response is the last response. HTTPDo buffers its body, which stays
+	// readable; HTTPRetryTransport drains and closes it for connection reuse.
+	Response *http.Response
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("retryable status: %d", e.StatusCode)
+}
+
+// PanicError is the error RetryMiddleware reports for an attempt whose
+// handler panicked, to the RetryLogger given with WithLogger among others, so
+// that panics can be told apart from failed responses.
+//
+// Example:
+//
+//	func (l *appLogger) LogAttempt(attempt int, err error) {
+//	    var panicErr *ebo.PanicError
+//	    if errors.As(err, &panicErr) {
+//	        l.Error("handler panicked", "value", panicErr.Value, "stack", string(panicErr.Stack))
+//	    }
+//	}
+type PanicError struct {
+	Value any    // The value the handler panicked with
+	Stack []byte // The stack of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panicked: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error, such as a runtime.Error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// retryableStatus returns the error reported for a retryable response,
+// carrying the wait given by after or else requested by its Retry-After
+// header, if any.
+func retryableStatus(resp *http.Response, after time.Duration) error {
+	var err error = &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Response: resp}
+	if after > 0 {
+		return RetryAfter(err, after)
+	}
+	if wait, ok := retryAfter(resp); ok {
+		return RetryAfter(err, wait)
+	}
+	return err
+}
+
+// seedFor draws the jitter of the retry loop for req from a source seeded by
+// the JitterSeedFunc, if set.
+func (c *RetryConfig) seedFor(req *http.Request) {
+	if c.requestSeed != nil {
+		c.random = seededRandom(c.requestSeed(req))
+	}
+}
+
+// decide returns the retry decision for resp: the one of the CheckerWithDelay
+// if set, which the body checker can turn into a retry, or else checker's.
+func (c *RetryConfig) decide(checker ResponseChecker, resp *http.Response) RetryDecision {
+	if c.delayChecker == nil {
+		return RetryDecision{Retry: checker(resp)}
+	}
+	decision := c.delayChecker(resp)
+	if !decision.Retry && c.bodyChecker != nil {
+		decision.Retry = c.bodyChecker(resp)
+	}
+	return decision
+}
+
+// statusDelay returns the delay and jitter before attempt after err, computed
+// with the StatusBackoff option for the status of err, if any.
+func (c *RetryConfig) statusDelay(err error, attempt int) (time.Duration, time.Duration) {
+	var status *HTTPStatusError
+	if len(c.statusBackoff) > 0 && errors.As(err, &status) {
+		if opt, ok := c.statusBackoff[status.StatusCode]; ok {
+			override := *c
+			override.apply(opt)
+			return override.jitteredDelay(attempt)
+		}
+	}
+	return c.jitteredDelay(attempt)
+}
+
+// ParseRetryAfter parses a Retry-After header value given either as
+// delta-seconds or as an HTTP-date, relative to now. Dates in the past yield
+// a zero wait. It reports false for an empty or malformed value. The HTTP
+// helpers use it, and it is exported for custom checkers.
+//
+// Example:
+//
+//	checker := func(resp *http.Response) ebo.RetryDecision {
+//	    wait, ok := ebo.ParseRetryAfter(resp.Header.Get("X-RateLimit-Reset-After"), time.Now())
+//	    return ebo.RetryDecision{Retry: ok || resp.StatusCode >= 500, After: wait}
+//	}
+func ParseRetryAfter(h string, now time.Time) (time.Duration, bool) {
+	h = strings.TrimSpace(h)
+	if h == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(h); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// MiddlewareHook observes retries performed by RetryMiddleware for a request.
+// attempt is the number of attempts made so far and status is the status code
+// of the last one.
+type MiddlewareHook func(r *http.Request, attempt int, status int)
+
 // NewRetryMiddleware creates a new retry middleware with the given options
 func NewRetryMiddleware(next http.Handler, checker ResponseChecker, opts ...Option) *RetryMiddleware {
 	if checker == nil {
@@ -35,30 +300,85 @@ func NewRetryMiddleware(next http.Handler, checker ResponseChecker, opts ...Opti
 
 // ServeHTTP implements the http.Handler interface
 func (m *RetryMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Stop retrying, including mid-backoff, once the client goes away
+	config := newConfig(append(m.options[:len(m.options):len(m.options)], WithContext(r.Context()))...)
+	if config.bypassed(r) {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
 	// Create a response recorder to capture the response
 	recorder := newResponseRecorder()
+	config.seedFor(r)
+	checker := m.checker
+	if config.bodyChecker != nil {
+		checker = AnyChecker(checker, config.bodyChecker)
+	}
+	attempts := 0
+
+	// Failing over may send the request twice, so only idempotent ones get more than one attempt
+	upstreams := config.upstreams
+	if len(upstreams) > 0 && !isIdempotent(r.Method) {
+		config.MaxRetries = 1
+	}
+	if config.shuffleUpstreams {
+		upstreams = config.shuffle(upstreams)
+	}
+
+	// Report the failed attempt once its retry is scheduled, before the backoff sleep
+	if hook := config.middlewareOnRetry; hook != nil {
+		onRetry := config.onRetry
+		config.onRetry = func(attempt int, delay, jitter time.Duration) {
+			hook(r, attempt-1, recorder.Code)
+			if onRetry != nil {
+				onRetry(attempt, delay, jitter)
+			}
+		}
+	}
+
+	err := retry(config, func() error {
+		attempts++
 
-	err := Retry(func() error {
 		// Reset the recorder for each attempt
 		recorder.reset()
 
-		// Call the next handler
-		m.next.ServeHTTP(recorder, r)
+		// Call the next handler, pointed at the next upstream if any
+		req := r
+		if n := len(upstreams); n > 0 {
+			req = withUpstream(r, upstreams[(attempts-1)%n])
+		}
+		if err := m.serve(recorder, req); err != nil {
+			// Report a panic as a 500, which is retried unless PanicAsPermanent is set
+			recorder.reset()
+			http.Error(recorder, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			if config.panicAsPermanent {
+				return &permanentError{err}
+			}
+			return err
+		}
 
 		// Check if we should retry
 		result := recorder.Result()
-		shouldRetry := m.checker(result)
+		decision := config.decide(checker, result)
 		if result.Body != nil {
 			_ = result.Body.Close() // Close the body as required by bodyclose linter
 		}
-		if shouldRetry {
-			return fmt.Errorf("retryable status: %d", recorder.Code)
+		if decision.Retry {
+			var err error = &HTTPStatusError{StatusCode: result.StatusCode, Status: result.Status, Response: result}
+			if decision.After > 0 {
+				return RetryAfter(err, decision.After)
+			}
+			return err
 		}
 
 		return nil
-	}, m.options...)
+	})
 
 	if err != nil {
+		if config.middlewareOnGiveUp != nil {
+			config.middlewareOnGiveUp(r, attempts, recorder.Code)
+		}
+
 		// If all retries failed, write the last response
 		recorder.writeTo(w)
 		return
@@ -68,6 +388,76 @@ func (m *RetryMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	recorder.writeTo(w)
 }
 
+// serve calls the next handler, recovering any panic as a *PanicError.
+// http.ErrAbortHandler is re-panicked, as it is meant to abort the request.
+func (m *RetryMiddleware) serve(w http.ResponseWriter, r *http.Request) (err *PanicError) {
+	defer func() {
+		p := recover()
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
+		if p != nil {
+			err = &PanicError{Value: p, Stack: debug.Stack()}
+		}
+	}()
+	m.next.ServeHTTP(w, r)
+	return nil
+}
+
+// bypassed reports whether r carries the BypassHeader, with one of its values if any.
+func (c *RetryConfig) bypassed(r *http.Request) bool {
+	if c.bypassHeader == "" {
+		return false
+	}
+	got, ok := r.Header[http.CanonicalHeaderKey(c.bypassHeader)]
+	if !ok {
+		return false
+	}
+	if len(c.bypassValues) == 0 {
+		return true
+	}
+	for _, value := range c.bypassValues {
+		if slices.Contains(got, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdempotent reports whether requests with the given method may safely be sent more than once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// shuffle returns a copy of upstreams in random order, drawn from the
+// configured source so that seeded jitter also seeds the order.
+func (c *RetryConfig) shuffle(upstreams []*url.URL) []*url.URL {
+	shuffled := slices.Clone(upstreams)
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j := int(c.float64() * float64(i+1))
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	return shuffled
+}
+
+// withUpstream returns a copy of r whose URL and Host point at upstream.
+// The upstream path, if any, is prefixed to the request path.
+func withUpstream(r *http.Request, upstream *url.URL) *http.Request {
+	req := r.Clone(r.Context())
+	req.URL.Scheme = upstream.Scheme
+	req.URL.Host = upstream.Host
+	if upstream.Path != "" {
+		req.URL.Path = strings.TrimSuffix(upstream.Path, "/") + "/" + strings.TrimPrefix(r.URL.Path, "/")
+		req.URL.RawPath = ""
+	}
+	req.Host = upstream.Host
+	return req
+}
+
 // Middleware returns a middleware function compatible with popular routers
 func Middleware(checker ResponseChecker, opts ...Option) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -109,6 +499,26 @@ func (r *responseRecorder) Write(b []byte) (int, error) {
 	return len(b), nil
 }
 
+// WriteString buffers s like Write, for handlers that use io.WriteString.
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.Body = append(r.Body, s...)
+	return len(s), nil
+}
+
+// ReadFrom buffers everything read from src like Write, for handlers that use io.Copy.
+func (r *responseRecorder) ReadFrom(src io.Reader) (int64, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	buf := bytes.NewBuffer(r.Body)
+	n, err := buf.ReadFrom(src)
+	r.Body = buf.Bytes()
+	return n, err
+}
+
 func (r *responseRecorder) WriteHeader(code int) {
 	if r.wroteHeader {
 		return
@@ -119,8 +529,10 @@ func (r *responseRecorder) WriteHeader(code int) {
 
 func (r *responseRecorder) Result() *http.Response {
 	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", r.Code, http.StatusText(r.Code)),
 		StatusCode: r.Code,
 		Header:     r.Headers,
+		Body:       io.NopCloser(bytes.NewReader(r.Body)),
 	}
 }
 
@@ -1,9 +1,17 @@
 package ebo
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"runtime"
+	"slices"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -189,6 +197,680 @@ func TestRetryMiddleware(t *testing.T) {
 	})
 }
 
+func TestRetryAfterChecker(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       bool
+	}{
+		{"429 with seconds", http.StatusTooManyRequests, "2", true},
+		{"429 with http date", http.StatusTooManyRequests, time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), true},
+		{"429 without header", http.StatusTooManyRequests, "", false},
+		{"429 with garbage header", http.StatusTooManyRequests, "soon", false},
+		{"429 with negative seconds", http.StatusTooManyRequests, "-1", false},
+		{"503 without header", http.StatusServiceUnavailable, "", true},
+		{"200", http.StatusOK, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: make(http.Header)}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+			if got := RetryAfterChecker(resp); got != tt.want {
+				t.Errorf("RetryAfterChecker() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("middleware stops on 429 without Retry-After", func(t *testing.T) {
+		attempts := int32(0)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		middleware := NewRetryMiddleware(handler, RetryAfterChecker,
+			Initial(10*time.Millisecond),
+			Tries(3))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		rec := httptest.NewRecorder()
+
+		middleware.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusTooManyRequests {
+			t.Errorf("expected status 429, got %d", rec.Code)
+		}
+		if atomic.LoadInt32(&attempts) != 1 {
+			t.Errorf("expected 1 attempt, got %d", atomic.LoadInt32(&attempts))
+		}
+	})
+
+	t.Run("middleware retries 429 with Retry-After", func(t *testing.T) {
+		attempts := int32(0)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) < 2 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+
+		middleware := NewRetryMiddleware(handler, RetryAfterChecker,
+			Initial(10*time.Millisecond),
+			Tries(3))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		rec := httptest.NewRecorder()
+
+		middleware.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rec.Code)
+		}
+		if atomic.LoadInt32(&attempts) != 2 {
+			t.Errorf("expected 2 attempts, got %d", atomic.LoadInt32(&attempts))
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"seconds", "120", 2 * time.Minute, true},
+		{"zero seconds", "0", 0, true},
+		{"padded seconds", " 5 ", 5 * time.Second, true},
+		{"http date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{"past date", now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		{"empty", "", 0, false},
+		{"negative seconds", "-3", 0, false},
+		{"garbage", "soon", 0, false},
+		{"fractional seconds", "1.5", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseRetryAfter(tt.header, now)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ParseRetryAfter(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRetryAfterClockSkew(t *testing.T) {
+	skewed := func(offset time.Duration, wait time.Duration) *http.Response {
+		serverNow := time.Now().Add(offset).UTC().Truncate(time.Second)
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: make(http.Header)}
+		resp.Header.Set("Date", serverNow.Format(http.TimeFormat))
+		resp.Header.Set("Retry-After", serverNow.Add(wait).Format(http.TimeFormat))
+		return resp
+	}
+
+	for _, offset := range []time.Duration{-time.Hour, 0, time.Hour} {
+		if got, ok := retryAfter(skewed(offset, 3*time.Second)); !ok || got != 3*time.Second {
+			t.Errorf("server clock offset %v: expected 3s wait, got %v (ok=%v)", offset, got, ok)
+		}
+	}
+
+	t.Run("without Date uses client clock", func(t *testing.T) {
+		resp := &http.Response{Header: make(http.Header)}
+		resp.Header.Set("Retry-After", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		if got, ok := retryAfter(resp); !ok || got != 0 {
+			t.Errorf("expected 0 wait for a past date, got %v (ok=%v)", got, ok)
+		}
+	})
+
+	t.Run("HTTPDo waits relative to server Date", func(t *testing.T) {
+		var times []time.Time
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			times = append(times, time.Now())
+			if len(times) == 1 {
+				// Server clock is an hour behind the client
+				serverNow := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+				w.Header().Set("Date", serverNow.Format(http.TimeFormat))
+				w.Header().Set("Retry-After", serverNow.Add(time.Second).Format(http.TimeFormat))
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := HTTPDo(req, nil, Initial(time.Millisecond), Max(5*time.Second), Tries(2))
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if len(times) != 2 {
+			t.Fatalf("expected 2 attempts, got %d", len(times))
+		}
+		if wait := times[1].Sub(times[0]); wait < 900*time.Millisecond || wait > 2*time.Second {
+			t.Errorf("expected ~1s wait, got %v", wait)
+		}
+	})
+
+	t.Run("wait is capped at Max", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts == 1 {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		start := time.Now()
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		resp, err := HTTPDo(req, nil, Initial(time.Millisecond), Max(20*time.Millisecond), Tries(2))
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected wait capped at 20ms, took %v", elapsed)
+		}
+	})
+}
+
+func TestCheckHeader(t *testing.T) {
+	t.Run("matches header value", func(t *testing.T) {
+		checker := CheckHeader("X-RateLimit-Remaining", "0")
+
+		resp := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header)}
+		resp.Header.Set("X-RateLimit-Remaining", "0")
+		if !checker(resp) {
+			t.Error("expected retry when header matches")
+		}
+
+		resp.Header.Set("X-RateLimit-Remaining", "10")
+		if checker(resp) {
+			t.Error("expected no retry when header differs")
+		}
+
+		resp.Header.Del("X-RateLimit-Remaining")
+		if checker(resp) {
+			t.Error("expected no retry when header is missing")
+		}
+	})
+
+	t.Run("middleware retries on header", func(t *testing.T) {
+		attempts := int32(0)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+			} else {
+				w.Header().Set("X-RateLimit-Remaining", "99")
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("ok"))
+		})
+
+		middleware := NewRetryMiddleware(handler, CheckHeader("X-RateLimit-Remaining", "0"),
+			Initial(10*time.Millisecond),
+			Tries(5))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		rec := httptest.NewRecorder()
+
+		middleware.ServeHTTP(rec, req)
+
+		if atomic.LoadInt32(&attempts) != 3 {
+			t.Errorf("expected 3 attempts, got %d", atomic.LoadInt32(&attempts))
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != "99" {
+			t.Errorf("expected final header value 99, got %q", got)
+		}
+	})
+}
+
+func TestAnyChecker(t *testing.T) {
+	notFound := func(resp *http.Response) bool {
+		return resp.StatusCode == http.StatusNotFound
+	}
+	checker := AnyChecker(DefaultResponseChecker, notFound)
+
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusInternalServerError, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusNotFound, true},
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		if got := checker(&http.Response{StatusCode: tt.status}); got != tt.want {
+			t.Errorf("status %d: expected %v, got %v", tt.status, tt.want, got)
+		}
+	}
+
+	t.Run("short-circuits on first true", func(t *testing.T) {
+		calls := 0
+		counting := func(*http.Response) bool {
+			calls++
+			return false
+		}
+
+		combined := AnyChecker(counting, DefaultResponseChecker, counting)
+		if !combined(&http.Response{StatusCode: http.StatusBadGateway}) {
+			t.Error("expected retry for 502")
+		}
+		if calls != 1 {
+			t.Errorf("expected checkers after the first match to be skipped, got %d calls", calls)
+		}
+	})
+
+	t.Run("no checkers never retries", func(t *testing.T) {
+		if AnyChecker(nil)(&http.Response{StatusCode: http.StatusInternalServerError}) {
+			t.Error("expected no retry without checkers")
+		}
+	})
+}
+
+func TestMiddlewareHooks(t *testing.T) {
+	t.Run("retry hook fires before the backoff sleep", func(t *testing.T) {
+		attempts := 0
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		})
+
+		logger := &captureLogger{}
+		record := func(event string) { logger.record("%s", event) }
+		Middleware(DefaultResponseChecker,
+			Initial(time.Millisecond),
+			Tries(3),
+			WithLogger(logger),
+			WithSleepHook(func() { record("sleep") }, nil),
+			MiddlewareOnRetry(func(_ *http.Request, attempt, status int) {
+				record(fmt.Sprintf("hook %d: %d", attempt, status))
+			}),
+		)(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		want := []string{
+			"attempt 1: retryable status: 502",
+			"retry 2",
+			"hook 1: 502",
+			"sleep",
+			"attempt 2: <nil>",
+		}
+		if !slices.Equal(logger.events, want) {
+			t.Errorf("unexpected events:\n got: %q\nwant: %q", logger.events, want)
+		}
+	})
+
+	t.Run("retry hook fires for each retry", func(t *testing.T) {
+		attempts := int32(0)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) <= 2 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+
+		var retried []int
+		var statuses []int
+		gaveUp := false
+		middleware := Middleware(DefaultResponseChecker,
+			Initial(10*time.Millisecond),
+			Tries(5),
+			MiddlewareOnRetry(func(r *http.Request, attempt, status int) {
+				retried = append(retried, attempt)
+				statuses = append(statuses, status)
+			}),
+			MiddlewareOnGiveUp(func(*http.Request, int, int) {
+				gaveUp = true
+			}),
+		)(handler)
+
+		req := httptest.NewRequest("GET", "/users", nil)
+		rec := httptest.NewRecorder()
+
+		middleware.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rec.Code)
+		}
+		if len(retried) != 2 || retried[0] != 1 || retried[1] != 2 {
+			t.Errorf("expected retry hook for attempts [1 2], got %v", retried)
+		}
+		for _, status := range statuses {
+			if status != http.StatusBadGateway {
+				t.Errorf("expected status 502 in retry hook, got %d", status)
+			}
+		}
+		if gaveUp {
+			t.Error("give up hook should not fire on success")
+		}
+	})
+
+	t.Run("give up hook fires when retries are exhausted", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+
+		var gotAttempts, gotStatus int
+		var gotPath string
+		middleware := NewRetryMiddleware(handler, DefaultResponseChecker,
+			Initial(10*time.Millisecond),
+			Tries(3),
+			MiddlewareOnGiveUp(func(r *http.Request, attempts, status int) {
+				gotPath = r.URL.Path
+				gotAttempts = attempts
+				gotStatus = status
+			}),
+		)
+
+		req := httptest.NewRequest("GET", "/orders", nil)
+		rec := httptest.NewRecorder()
+
+		middleware.ServeHTTP(rec, req)
+
+		if gotPath != "/orders" {
+			t.Errorf("expected path /orders, got %q", gotPath)
+		}
+		if gotAttempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", gotAttempts)
+		}
+		if gotStatus != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", gotStatus)
+		}
+	})
+}
+
+func TestMiddlewareDelayChecker(t *testing.T) {
+	var calls []time.Time
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, time.Now())
+		if len(calls) < 2 {
+			w.Header().Set("X-Retry-In", "50ms")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Retry conflicts after the delay the handler asks for
+	checker := func(resp *http.Response) RetryDecision {
+		after, _ := time.ParseDuration(resp.Header.Get("X-Retry-In"))
+		return RetryDecision{Retry: resp.StatusCode == http.StatusConflict, After: after}
+	}
+	middleware := Middleware(nil, Initial(time.Millisecond), NoJitter(), Tries(3), WithDelayChecker(checker))(handler)
+
+	rec := httptest.NewRecorder()
+	middleware.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rec.Code)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(calls))
+	}
+	if gap := calls[1].Sub(calls[0]); gap < 50*time.Millisecond {
+		t.Errorf("expected to wait at least 50ms, waited %v", gap)
+	}
+}
+
+func TestMiddlewarePanics(t *testing.T) {
+	t.Run("panic is retried", func(t *testing.T) {
+		attempts := int32(0)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				w.Header().Set("X-Partial", "yes")
+				panic("nil map")
+			}
+			_, _ = w.Write([]byte("ok"))
+		})
+
+		var statuses []int
+		middleware := Middleware(DefaultResponseChecker,
+			Initial(10*time.Millisecond),
+			Tries(3),
+			MiddlewareOnRetry(func(_ *http.Request, _, status int) {
+				statuses = append(statuses, status)
+			}),
+		)(handler)
+
+		rec := httptest.NewRecorder()
+		middleware.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+			t.Errorf("expected 200 ok, got %d %q", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("X-Partial") != "" {
+			t.Error("expected headers from the panicking attempt to be discarded")
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+		if len(statuses) != 1 || statuses[0] != http.StatusInternalServerError {
+			t.Errorf("expected retry hook with status 500, got %v", statuses)
+		}
+	})
+
+	t.Run("panic as permanent", func(t *testing.T) {
+		attempts := int32(0)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			panic("nil map")
+		})
+
+		var gotStatus int
+		middleware := Middleware(DefaultResponseChecker,
+			Initial(10*time.Millisecond),
+			Tries(3),
+			PanicAsPermanent(),
+			MiddlewareOnGiveUp(func(_ *http.Request, _, status int) {
+				gotStatus = status
+			}),
+		)(handler)
+
+		rec := httptest.NewRecorder()
+		middleware.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500, got %d", rec.Code)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+		if gotStatus != http.StatusInternalServerError {
+			t.Errorf("expected give up hook with status 500, got %d", gotStatus)
+		}
+	})
+
+	t.Run("panic reaches the logger as a PanicError", func(t *testing.T) {
+		handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			var m map[string]int
+			m["key"]++
+		})
+
+		logger := &errorLogger{}
+		Middleware(DefaultResponseChecker,
+			Initial(time.Millisecond),
+			Tries(2),
+			WithLogger(logger),
+		)(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		if len(logger.attempts) != 2 {
+			t.Fatalf("expected 2 logged attempts, got %d", len(logger.attempts))
+		}
+		for _, err := range append(logger.attempts, logger.gaveUp) {
+			var panicErr *PanicError
+			if !errors.As(err, &panicErr) {
+				t.Fatalf("expected a *PanicError, got %T: %v", err, err)
+			}
+			var runtimeErr runtime.Error
+			if !errors.As(err, &runtimeErr) {
+				t.Errorf("expected the runtime.Error to be unwrapped, got %v", panicErr.Value)
+			}
+			if !strings.Contains(string(panicErr.Stack), "TestMiddlewarePanics") {
+				t.Errorf("expected the stack of the handler, got %s", panicErr.Stack)
+			}
+		}
+	})
+
+	t.Run("abort handler is not recovered", func(t *testing.T) {
+		handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		})
+		middleware := Middleware(DefaultResponseChecker, Tries(3))(handler)
+
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Errorf("expected http.ErrAbortHandler to propagate, got %v", p)
+			}
+		}()
+		middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
+
+// errorLogger records the errors of attempts and of giving up.
+type errorLogger struct {
+	attempts []error
+	gaveUp   error
+}
+
+func (l *errorLogger) LogAttempt(_ int, err error) { l.attempts = append(l.attempts, err) }
+func (l *errorLogger) LogRetry(int, time.Duration) {}
+func (l *errorLogger) LogGiveUp(_ int, err error)  { l.gaveUp = err }
+
+func TestMiddlewareRetryOnJSONField(t *testing.T) {
+	attempts := int32(0)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			_, _ = io.WriteString(w, `{"status": "busy"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"status": "done"}`)
+	})
+
+	rec := httptest.NewRecorder()
+	NewRetryMiddleware(handler, nil, Initial(time.Millisecond), Tries(5), RetryOnJSONField("status", "busy")).
+		ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Body.String() != `{"status": "done"}` {
+		t.Errorf("expected final body, got %q", rec.Body.String())
+	}
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Errorf("expected 3 attempts, got %d", n)
+	}
+}
+
+func TestUpstreams(t *testing.T) {
+	var primaryHits, secondaryHits int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryHits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&secondaryHits, 1)
+		_, _ = io.WriteString(w, "from secondary "+r.URL.Path)
+	}))
+	defer secondary.Close()
+
+	primaryURL, _ := url.Parse(primary.URL)
+	secondaryURL, _ := url.Parse(secondary.URL)
+	proxy := &httputil.ReverseProxy{Director: func(*http.Request) {}}
+	handler := NewRetryMiddleware(proxy, nil, Initial(time.Millisecond), Tries(3), Upstreams(primaryURL, secondaryURL))
+
+	t.Run("GET fails over to secondary", func(t *testing.T) {
+		atomic.StoreInt32(&primaryHits, 0)
+		atomic.StoreInt32(&secondaryHits, 0)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/items", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rec.Code)
+		}
+		if rec.Body.String() != "from secondary /items" {
+			t.Errorf("expected body from secondary, got %q", rec.Body.String())
+		}
+		if p, s := atomic.LoadInt32(&primaryHits), atomic.LoadInt32(&secondaryHits); p != 1 || s != 1 {
+			t.Errorf("expected 1 hit each, got primary=%d secondary=%d", p, s)
+		}
+	})
+
+	t.Run("POST is not failed over", func(t *testing.T) {
+		atomic.StoreInt32(&primaryHits, 0)
+		atomic.StoreInt32(&secondaryHits, 0)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", "/items", strings.NewReader("{}")))
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", rec.Code)
+		}
+		if p, s := atomic.LoadInt32(&primaryHits), atomic.LoadInt32(&secondaryHits); p != 1 || s != 0 {
+			t.Errorf("expected only the primary to be hit once, got primary=%d secondary=%d", p, s)
+		}
+	})
+}
+
+func TestShuffleUpstreams(t *testing.T) {
+	// first records which upstream a request reached first, as its index + 1
+	var first atomic.Int32
+	var upstreams []*url.URL
+	for i := range 3 {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			first.CompareAndSwap(0, int32(i+1))
+			if i != 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = io.WriteString(w, "ok")
+		}))
+		defer server.Close()
+		u, _ := url.Parse(server.URL)
+		upstreams = append(upstreams, u)
+	}
+
+	var seed atomic.Int64
+	proxy := &httputil.ReverseProxy{Director: func(*http.Request) {}}
+	handler := NewRetryMiddleware(proxy, nil, Initial(time.Millisecond), Tries(3), Upstreams(upstreams...), ShuffleUpstreams(),
+		JitterSeedFunc(func(*http.Request) int64 { return seed.Add(1) }))
+
+	firsts := map[int32]bool{}
+	for range 20 {
+		first.Store(0)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/items", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		firsts[first.Load()] = true
+	}
+	if len(firsts) < 2 {
+		t.Errorf("expected requests to start on different upstreams, got %v", firsts)
+	}
+}
+
 func TestResponseRecorder(t *testing.T) {
 	t.Run("basic recording", func(t *testing.T) {
 		recorder := newResponseRecorder()
@@ -206,6 +888,11 @@ func TestResponseRecorder(t *testing.T) {
 		if recorder.Header().Get("Content-Type") != "text/plain" {
 			t.Errorf("expected content-type header")
 		}
+
+		result := recorder.Result()
+		if result.Header.Get("Content-Type") != "text/plain" {
+			t.Errorf("expected content-type header on result")
+		}
 	})
 
 	t.Run("default status code", func(t *testing.T) {
@@ -236,6 +923,44 @@ func TestResponseRecorder(t *testing.T) {
 			t.Errorf("expected headers to be reset")
 		}
 	})
+
+	t.Run("io.Copy and io.WriteString", func(t *testing.T) {
+		recorder := newResponseRecorder()
+		var w http.ResponseWriter = recorder
+		if _, ok := w.(io.ReaderFrom); !ok {
+			t.Fatal("expected recorder to implement io.ReaderFrom")
+		}
+
+		_, _ = io.WriteString(w, "head ")
+		n, err := io.Copy(w, strings.NewReader("copied body"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if n != int64(len("copied body")) {
+			t.Errorf("expected %d bytes copied, got %d", len("copied body"), n)
+		}
+		if string(recorder.Body) != "head copied body" {
+			t.Errorf("expected body 'head copied body', got %q", recorder.Body)
+		}
+		if recorder.Code != http.StatusOK {
+			t.Errorf("expected default status 200, got %d", recorder.Code)
+		}
+	})
+
+	t.Run("handler using io.Copy through middleware", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = io.Copy(w, strings.NewReader("streamed"))
+		})
+
+		rec := httptest.NewRecorder()
+		NewRetryMiddleware(handler, DefaultResponseChecker, Tries(2)).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		if rec.Body.String() != "streamed" {
+			t.Errorf("expected body 'streamed', got %q", rec.Body.String())
+		}
+	})
 }
 
 func BenchmarkRetryMiddleware(b *testing.B) {
@@ -306,3 +1031,138 @@ func ExampleRetryMiddleware() {
 	body, _ := io.ReadAll(resp.Body)
 	println(string(body))
 }
+
+func TestBypassHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		header   string
+		expected int32
+	}{
+		{"any value bypasses", nil, "yes", 1},
+		{"matching value bypasses", []string{"1", "true"}, "true", 1},
+		{"other value retries", []string{"1"}, "0", 2},
+		{"no header retries", nil, "", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := int32(0)
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.Header().Set("X-Upstream", "failed")
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("boom"))
+			})
+
+			middleware := Middleware(DefaultResponseChecker,
+				Initial(time.Millisecond),
+				Tries(2),
+				BypassHeader("X-Ebo-Bypass", tt.values...),
+			)(handler)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("x-ebo-bypass", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			middleware.ServeHTTP(rec, req)
+
+			if attempts != tt.expected {
+				t.Errorf("expected %d attempts, got %d", tt.expected, attempts)
+			}
+			if rec.Code != http.StatusInternalServerError || rec.Body.String() != "boom" || rec.Header().Get("X-Upstream") != "failed" {
+				t.Errorf("expected the handler's 500 response, got %d %q", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// stepClock is a Clock whose timers fire only when the test steps it.
+type stepClock struct {
+	sleeps chan time.Duration // Receives the duration of every sleep started
+	fire   chan time.Time     // Fires the pending sleep
+}
+
+func newStepClock() *stepClock {
+	return &stepClock{sleeps: make(chan time.Duration), fire: make(chan time.Time)}
+}
+
+func (c *stepClock) After(d time.Duration) <-chan time.Time {
+	c.sleeps <- d
+	return c.fire
+}
+
+func TestMiddlewareClock(t *testing.T) {
+	attempts := int32(0)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	clock := newStepClock()
+	middleware := Middleware(DefaultResponseChecker,
+		Initial(time.Hour), Max(time.Hour), NoJitter(), Tries(3), WithClock(clock),
+	)(handler)
+
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		middleware.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	}()
+
+	for want := int32(1); want < 3; want++ {
+		if d := <-clock.sleeps; d != time.Hour {
+			t.Errorf("expected a 1h backoff, got %v", d)
+		}
+		if got := atomic.LoadInt32(&attempts); got != want {
+			t.Errorf("expected %d attempts before sleep %d, got %d", want, want, got)
+		}
+		clock.fire <- time.Now()
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("middleware did not finish after the clock was advanced")
+	}
+	if attempts != 3 || rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 3 attempts ending in 503, got %d and %d", attempts, rec.Code)
+	}
+}
+
+func TestMiddlewareRequestContext(t *testing.T) {
+	attempts := int32(0)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	clock := newStepClock()
+	middleware := Middleware(DefaultResponseChecker,
+		Initial(time.Hour), Max(time.Hour), NoJitter(), Tries(3), WithClock(clock),
+	)(handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		middleware.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	// Cancel the request while the first backoff is in progress
+	<-clock.sleeps
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("middleware kept sleeping after the request was cancelled")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("expected no attempts after cancellation, got %d attempts", got)
+	}
+}
@@ -0,0 +1,53 @@
+package ebo
+
+import "io"
+
+// retryReader retries failed reads on the wrapped reader
+type retryReader struct {
+	r      io.Reader
+	config *RetryConfig
+}
+
+// RetryReader wraps r so that a failed Read is retried with exponential
+// backoff instead of aborting the whole copy. io.EOF is passed through as is,
+// and the last read error is returned once the configured attempts run out.
+//
+// The wrapped reader must be resumable: after a failed Read, the next Read has
+// to continue where the last successful one stopped (for example a reader that
+// reconnects using a Range header or seeks back to its offset). Otherwise data
+// may be lost or duplicated.
+//
+// Example:
+//
+//	body := ebo.RetryReader(resumableDownload, ebo.Tries(5), ebo.Initial(200*time.Millisecond))
+//	if _, err := io.Copy(file, body); err != nil {
+//	    return err
+//	}
+func RetryReader(r io.Reader, opts ...Option) io.Reader {
+	return &retryReader{r: r, config: newConfig(opts...)}
+}
+
+// Read implements io.Reader
+func (r *retryReader) Read(p []byte) (int, error) {
+	var n int
+	var eof error
+
+	err := retry(r.config, func() error {
+		var err error
+		n, err = r.r.Read(p)
+		switch {
+		case err == io.EOF:
+			eof = err
+			return nil
+		case err != nil && n > 0:
+			// Hand out the data now; the error surfaces again on the next Read
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return n, err
+	}
+
+	return n, eof
+}
@@ -0,0 +1,70 @@
+package ebo
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// flakyReader fails a number of times before reading from the underlying data.
+type flakyReader struct {
+	data     []byte
+	failures int
+	reads    int
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	f.reads++
+	if f.failures > 0 {
+		f.failures--
+		return 0, errors.New("connection reset")
+	}
+	if len(f.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+	return n, nil
+}
+
+func TestRetryReader(t *testing.T) {
+	t.Run("retries failed read", func(t *testing.T) {
+		src := &flakyReader{data: []byte("hello world"), failures: 1}
+
+		got, err := io.ReadAll(RetryReader(src, Initial(time.Millisecond), Tries(3)))
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		if string(got) != "hello world" {
+			t.Errorf("expected 'hello world', got %q", got)
+		}
+		if src.reads != 3 {
+			t.Errorf("expected 3 reads (failure, data, EOF), got %d", src.reads)
+		}
+	})
+
+	t.Run("gives up after attempts", func(t *testing.T) {
+		src := &flakyReader{data: []byte("data"), failures: 10}
+
+		_, err := io.ReadAll(RetryReader(src, Initial(time.Millisecond), Tries(3)))
+		if err == nil || err.Error() != "connection reset" {
+			t.Errorf("expected read error, got %v", err)
+		}
+		if src.reads != 3 {
+			t.Errorf("expected 3 reads, got %d", src.reads)
+		}
+	})
+
+	t.Run("passes through EOF", func(t *testing.T) {
+		src := &flakyReader{}
+
+		n, err := RetryReader(src, Tries(3)).Read(make([]byte, 8))
+		if n != 0 || err != io.EOF {
+			t.Errorf("expected (0, io.EOF), got (%d, %v)", n, err)
+		}
+		if src.reads != 1 {
+			t.Errorf("expected 1 read, got %d", src.reads)
+		}
+	})
+}
@@ -3,23 +3,94 @@ package ebo
 import (
 	"context"
 	"errors"
+	"fmt"
 	"iter"
+	"log/slog"
 	"time"
 )
 
+// maxErrorHistory bounds Attempt.Errors so that long running loops do not grow it forever
+const maxErrorHistory = 100
+
 // Attempt represents a single retry attempt
 type Attempt struct {
-	Number    int           // Attempt number, starting from 1
-	Delay     time.Duration // Time to wait before this attempt
-	Elapsed   time.Duration // Total elapsed time since first attempt
-	LastError error         // Error from previous attempt (nil on first attempt)
-	Context   context.Context
+	Number        int           // Attempt number, starting from 1
+	Delay         time.Duration // Time to wait before this attempt
+	JitterApplied time.Duration // Signed offset jitter added to the base delay to get Delay
+	Elapsed       time.Duration // Total elapsed time since first attempt
+	LastError     error         // Error from previous attempt (nil on first attempt)
+	Errors        []error       // Errors of earlier attempts, oldest first (at most the last 100)
+	MaxTries      int           // Configured maximum number of attempts (0 for no limit)
+	MaxInterval   time.Duration // Configured cap on the delay between attempts
+	Context       context.Context
+
+	inherited bool // LastError was carried over from the previous attempt by DoWithAttempts
+}
+
+// RemainingTries returns how many attempts may follow this one under
+// MaxTries, or -1 without a limit. Other limits, such as MaxTime, may end
+// the iteration earlier.
+//
+// Example:
+//
+//	if attempt.RemainingTries() == 0 {
+//	    log.Printf("last attempt for %s", job.ID)
+//	}
+func (a *Attempt) RemainingTries() int {
+	if a.MaxTries <= 0 {
+		return -1
+	}
+	return max(a.MaxTries-a.Number, 0)
+}
+
+// AllErrors returns the errors of earlier attempts followed by the error of
+// this one, once the loop body has assigned it to LastError as in an Attempts
+// loop. It is handy for logging "failed N times with: ..." on the final attempt.
+func (a *Attempt) AllErrors() []error {
+	if a.LastError == nil || a.inherited {
+		return a.Errors
+	}
+	return append(a.Errors[:len(a.Errors):len(a.Errors)], a.LastError)
+}
+
+// String formats the attempt for logging, e.g.
+// "attempt 3 (delay 1.5s, elapsed 4s, last error: connection refused)".
+// The last error is omitted while LastError is nil.
+func (a *Attempt) String() string {
+	if a.LastError == nil {
+		return fmt.Sprintf("attempt %d (delay %v, elapsed %v)", a.Number, a.Delay, a.Elapsed)
+	}
+	return fmt.Sprintf("attempt %d (delay %v, elapsed %v, last error: %v)", a.Number, a.Delay, a.Elapsed, a.LastError)
+}
+
+// LogValue implements slog.LogValuer, logging the attempt as a group with
+// number, delay, elapsed and, if set, error fields.
+//
+// Example:
+//
+//	slog.Info("retrying", "attempt", attempt)
+func (a *Attempt) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("number", a.Number),
+		slog.Duration("delay", a.Delay),
+		slog.Duration("elapsed", a.Elapsed),
+	}
+	if a.LastError != nil {
+		attrs = append(attrs, slog.Any("error", a.LastError))
+	}
+	return slog.GroupValue(attrs...)
 }
 
 // Attempts creates an iterator that yields retry attempts with exponential backoff.
 // This is ideal for building custom retry logic, implementing complex patterns,
 // or when you need fine-grained control over the retry process.
 //
+// The first attempt is always yielded, however small MaxElapsedTime is, unless
+// the context is done.
+//
+// When neither MaxRetries nor MaxElapsedTime is set (Forever without MaxTime),
+// the iterator stops after DefaultMaxAttempts attempts instead of yielding forever.
+//
 // Example:
 //
 //	for attempt := range ebo.Attempts(ebo.Tries(3)) {
@@ -30,79 +101,12 @@ type Attempt struct {
 //	    log.Printf("Attempt %d failed", attempt.Number)
 //	}
 func Attempts(opts ...Option) iter.Seq[*Attempt] {
-	config := &RetryConfig{
-		InitialInterval: defaultInitialInterval,
-		MaxInterval:     defaultMaxInterval,
-		MaxRetries:      defaultMaxRetries,
-		Multiplier:      defaultMultiplier,
-		MaxElapsedTime:  defaultMaxElapsedTime,
-		RandomizeFactor: defaultRandomizeFactor,
-	}
-
-	for _, opt := range opts {
-		opt(config)
-	}
-
-	return func(yield func(*Attempt) bool) {
-		startTime := time.Now()
-		currentInterval := config.InitialInterval
-		elapsed := time.Duration(0)
-
-		for i := 0; ; i++ {
-			// Check max retries
-			if config.MaxRetries > 0 && i >= config.MaxRetries {
-				return
-			}
-
-			// Check max elapsed time
-			if config.MaxElapsedTime > 0 && elapsed > config.MaxElapsedTime {
-				return
-			}
-
-			// Create attempt with current delay
-			attempt := &Attempt{
-				Number:  i + 1,
-				Delay:   currentInterval,
-				Elapsed: elapsed,
-				Context: context.Background(),
-			}
-
-			// For the first attempt, set delay to 0
-			if i == 0 {
-				attempt.Delay = 0
-			}
-
-			// Wait before yielding (except for first attempt)
-			if i > 0 {
-				time.Sleep(currentInterval)
-				elapsed = time.Since(startTime)
-			}
-
-			// Yield attempt
-			if !yield(attempt) {
-				return
-			}
-
-			// Update interval for next iteration
-			if config.Multiplier > 0 {
-				currentInterval = time.Duration(float64(currentInterval) * config.Multiplier)
-			}
-
-			// Apply max interval cap
-			if currentInterval > config.MaxInterval {
-				currentInterval = config.MaxInterval
-			}
-
-			// Apply jitter if configured
-			if config.RandomizeFactor > 0 {
-				currentInterval = getNextInterval(currentInterval, config.RandomizeFactor)
-			}
-		}
-	}
+	return AttemptsWithContext(context.Background(), opts...)
 }
 
 // AttemptsWithContext creates an iterator with context support.
-// The iterator will stop if the context is cancelled.
+// The iterator will stop if the context is cancelled. It ends at the earlier of
+// the context deadline and MaxElapsedTime, never sleeping past that point.
 //
 // Example:
 //
@@ -115,81 +119,197 @@ func Attempts(opts ...Option) iter.Seq[*Attempt] {
 //	    }
 //	}
 func AttemptsWithContext(ctx context.Context, opts ...Option) iter.Seq[*Attempt] {
-	config := &RetryConfig{
-		InitialInterval: defaultInitialInterval,
-		MaxInterval:     defaultMaxInterval,
-		MaxRetries:      defaultMaxRetries,
-		Multiplier:      defaultMultiplier,
-		MaxElapsedTime:  defaultMaxElapsedTime,
-		RandomizeFactor: defaultRandomizeFactor,
+	return attempts(ctx, newConfig(opts...), nil)
+}
+
+// AttemptsIndexed is Attempts yielding the zero-based index of each attempt
+// along with it, which is always attempt.Number-1, so the loop body needs no
+// counter of its own.
+//
+// Example:
+//
+//	for i, attempt := range ebo.AttemptsIndexed(ebo.Tries(3)) {
+//	    endpoint := endpoints[i%len(endpoints)]
+//	    if attempt.LastError = call(endpoint); attempt.LastError == nil {
+//	        break
+//	    }
+//	}
+func AttemptsIndexed(opts ...Option) iter.Seq2[int, *Attempt] {
+	return func(yield func(int, *Attempt) bool) {
+		for attempt := range Attempts(opts...) {
+			if !yield(attempt.Number-1, attempt) {
+				return
+			}
+		}
 	}
+}
 
-	for _, opt := range opts {
-		opt(config)
+// attempts builds the attempt iterator for config. When reason is not nil,
+// it is set to the condition that ended the iteration.
+func attempts(ctx context.Context, config *RetryConfig, reason *StopReason) iter.Seq[*Attempt] {
+	stop := func(r StopReason) {
+		if reason != nil {
+			*reason = r
+		}
 	}
 
 	return func(yield func(*Attempt) bool) {
 		startTime := time.Now()
-		currentInterval := config.InitialInterval
-		elapsed := time.Duration(0)
+		slept := time.Duration(0)
+		var history []error
+
+		// Stop at whichever comes first: the context deadline or MaxElapsedTime
+		var (
+			deadline       time.Time
+			hasDeadline    bool
+			deadlineReason StopReason
+		)
+		setDeadline := func() {
+			deadline, hasDeadline = ctx.Deadline()
+			deadlineReason = ReasonContextCancelled
+			if config.MaxElapsedTime > 0 {
+				if limit := startTime.Add(config.MaxElapsedTime); !hasDeadline || limit.Before(deadline) {
+					deadline, hasDeadline = limit, true
+					deadlineReason = ReasonMaxElapsed
+				}
+			}
+		}
+		setDeadline()
+
+		// Draw the time spent, including the last loop body, from the time budget
+		budgetMark := startTime
+		if config.budget != nil {
+			defer config.budget.spendSince(&budgetMark)
+		}
 
 		for i := 0; ; i++ {
 			// Check context
 			if ctx.Err() != nil {
+				stop(ReasonContextCancelled)
+				return
+			}
+			if config.stopped() {
+				stop(ReasonAborted)
+				return
+			}
+			if config.budget != nil && config.budget.spendSince(&budgetMark) <= 0 {
+				stop(ReasonMaxElapsed)
 				return
 			}
 
 			// Check max retries
 			if config.MaxRetries > 0 && i >= config.MaxRetries {
+				stop(ReasonMaxAttempts)
+				return
+			}
+			if config.reachedSafetyLimit(i) {
+				stop(ReasonMaxAttempts)
 				return
 			}
 
-			// Check max elapsed time
-			if config.MaxElapsedTime > 0 && elapsed > config.MaxElapsedTime {
+			// Check the effective deadline. MaxElapsedTime never prevents the
+			// first attempt, only the context deadline can
+			limited := hasDeadline && (i > 0 || deadlineReason != ReasonMaxElapsed)
+			if limited && !time.Now().Before(deadline) {
+				stop(deadlineReason)
 				return
 			}
 
-			// Create attempt with current delay
+			// Create attempt with its delay (0 for the first attempt)
+			delay, jitter := config.jitteredDelay(i + 1)
 			attempt := &Attempt{
-				Number:  i + 1,
-				Delay:   currentInterval,
-				Elapsed: elapsed,
-				Context: ctx,
+				Number:        i + 1,
+				Delay:         delay,
+				JitterApplied: jitter,
+				Errors:        history,
+				MaxTries:      config.MaxRetries,
+				MaxInterval:   config.MaxInterval,
+				Context:       ctx,
+			}
+
+			// Stop once the backoff sleeps would exceed MaxCumulativeDelay
+			if config.maxCumulativeDelay > 0 && i > 0 {
+				if slept+attempt.Delay > config.maxCumulativeDelay {
+					stop(ReasonMaxElapsed)
+					return
+				}
+				slept += attempt.Delay
 			}
 
-			// For the first attempt, set delay to 0
-			if i == 0 {
-				attempt.Delay = 0
+			// Do not sleep into an exhausted time budget
+			if config.budget != nil && attempt.Delay > 0 && attempt.Delay >= config.budget.spendSince(&budgetMark) {
+				stop(ReasonMaxElapsed)
+				return
 			}
 
-			// Wait before yielding (except for first attempt)
 			if i > 0 {
-				select {
-				case <-time.After(currentInterval):
-					elapsed = time.Since(startTime)
-				case <-ctx.Done():
+				config.logRetry(attempt.Number, attempt.Delay)
+				if config.onRetry != nil {
+					config.onRetry(attempt.Number, attempt.Delay, attempt.JitterApplied)
+				}
+			}
+
+			// Wait before yielding, never sleeping past the effective deadline
+			if attempt.Delay > 0 {
+				wait := attempt.Delay
+				trimmed := false
+				if limited {
+					if remaining := time.Until(deadline); remaining < wait {
+						wait, trimmed = remaining, true
+					}
+				}
+
+				if err := config.wait(ctx, wait); err != nil {
+					stop(waitReason(err))
+					return
+				}
+				if trimmed {
+					stop(deadlineReason)
 					return
 				}
 			}
 
+			// Time spent paused counts toward neither MaxElapsedTime nor the time budget
+			if i > 0 && config.isPaused != nil {
+				paused, err := config.pause(ctx)
+				if err != nil {
+					stop(waitReason(err))
+					return
+				}
+				if paused > 0 {
+					startTime = startTime.Add(paused)
+					budgetMark = budgetMark.Add(paused)
+					setDeadline()
+				}
+			}
+			if i > 0 {
+				attempt.Elapsed = time.Since(startTime)
+			}
+
+			// Expose the attempt state through the context
+			attempt.Context = withRetryState(attempt.Context, RetryState{
+				Attempt: attempt.Number,
+				Elapsed: time.Since(startTime),
+			})
+
 			// Yield attempt
 			if !yield(attempt) {
+				stop(ReasonSuccess)
 				return
 			}
 
-			// Update interval for next iteration
-			if config.Multiplier > 0 {
-				currentInterval = time.Duration(float64(currentInterval) * config.Multiplier)
-			}
-
-			// Apply max interval cap
-			if currentInterval > config.MaxInterval {
-				currentInterval = config.MaxInterval
+			// The loop body may have cancelled the context; stop before doing more work
+			if ctx.Err() != nil {
+				stop(ReasonContextCancelled)
+				return
 			}
 
-			// Apply jitter if configured
-			if config.RandomizeFactor > 0 {
-				currentInterval = getNextInterval(currentInterval, config.RandomizeFactor)
+			// Record the error the loop body assigned, keeping the most recent ones
+			if attempt.LastError != nil && !attempt.inherited {
+				if len(history) == maxErrorHistory {
+					history = history[1:]
+				}
+				history = append(history[:len(history):len(history)], attempt.LastError)
 			}
 		}
 	}
@@ -197,6 +317,12 @@ func AttemptsWithContext(ctx context.Context, opts ...Option) iter.Seq[*Attempt]
 
 // DoWithAttempts provides a simple way to use the iterator pattern.
 // It's a convenience wrapper around the Attempts iterator.
+// Returning ErrStop from fn ends the loop with a nil error.
+//
+// When the attempts run out, the last error from fn is returned wrapped with
+// ErrMaxAttempts or ErrMaxElapsed, so both can be checked with errors.Is.
+// If fn was never called, for example because the context was already done,
+// the returned error wraps ErrNoAttempts and the cause.
 //
 // Example:
 //
@@ -204,27 +330,7 @@ func AttemptsWithContext(ctx context.Context, opts ...Option) iter.Seq[*Attempt]
 //	    return apiCall()
 //	}, ebo.Tries(5))
 func DoWithAttempts(fn func(*Attempt) error, opts ...Option) error {
-	var lastErr error
-
-	for attempt := range Attempts(opts...) {
-		if err := fn(attempt); err == nil {
-			return nil
-		} else {
-			lastErr = err
-
-			// Check if it's a permanent error
-			var permanent *permanentError
-			if errors.As(err, &permanent) {
-				return permanent.err
-			}
-			attempt.LastError = err
-		}
-	}
-
-	if lastErr != nil {
-		return lastErr
-	}
-	return errors.New("all retry attempts failed")
+	return doWithAttempts(context.Background(), fn, newConfig(opts...))
 }
 
 // DoWithAttemptsContext provides context-aware iteration.
@@ -237,29 +343,56 @@ func DoWithAttempts(fn func(*Attempt) error, opts ...Option) error {
 //	    return apiCall(attempt.Context)
 //	}, ebo.Tries(3))
 func DoWithAttemptsContext(ctx context.Context, fn func(*Attempt) error, opts ...Option) error {
+	return doWithAttempts(ctx, fn, newConfig(opts...))
+}
+
+// doWithAttempts runs fn for every attempt until it succeeds or the attempts run out.
+func doWithAttempts(ctx context.Context, fn func(*Attempt) error, config *RetryConfig) error {
 	var lastErr error
+	var reason StopReason
+	count := 0
 
-	for attempt := range AttemptsWithContext(ctx, opts...) {
-		if err := fn(attempt); err == nil {
+	for attempt := range attempts(ctx, config, &reason) {
+		attempt.LastError, attempt.inherited = lastErr, lastErr != nil
+
+		var end func(error)
+		attempt.Context, end = config.startSpan(attempt.Context)
+		err := fn(attempt)
+		end(err)
+		count = attempt.Number
+		config.logAttempt(count, err)
+
+		if err == nil || errors.Is(err, ErrStop) {
 			return nil
-		} else {
-			lastErr = err
+		}
+		config.cleanupAttempt(count)
+		lastErr = err
+		attempt.LastError, attempt.inherited = err, false // Recorded in Errors by the iterator
+
+		// Check if it's a permanent error
+		if permErr, ok := config.permanent(err); ok {
+			_, err = config.giveUp(count, ReasonPermanent, permErr)
+			return err
+		}
+	}
 
-			// Check if it's a permanent error
-			var permanent *permanentError
-			if errors.As(err, &permanent) {
-				return permanent.err
-			}
-			attempt.LastError = err
+	// Tell "never tried" apart from "tried and failed"
+	if count == 0 {
+		err := ErrNoAttempts
+		if cause := ctx.Err(); cause != nil {
+			err = fmt.Errorf("%w: %w", ErrNoAttempts, cause)
+		} else if cause := stopError(reason, nil); cause != nil {
+			err = fmt.Errorf("%w: %w", ErrNoAttempts, cause)
 		}
+		_, err = config.giveUp(0, reason, err)
+		return err
 	}
 
 	if ctx.Err() != nil {
-		return ctx.Err()
+		_, err := config.giveUp(count, ReasonContextCancelled, ctx.Err())
+		return err
 	}
 
-	if lastErr != nil {
-		return lastErr
-	}
-	return errors.New("all retry attempts failed")
+	_, err := config.giveUp(count, reason, stopError(reason, lastErr))
+	return err
 }
@@ -1,8 +1,15 @@
 package ebo
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -52,6 +59,30 @@ func TestAttempts(t *testing.T) {
 		}
 	})
 
+	t.Run("immediate first retry", func(t *testing.T) {
+		var delays []time.Duration
+
+		for attempt := range Attempts(
+			Tries(3),
+			Initial(10*time.Millisecond),
+			Multiplier(1.0),
+			NoJitter(),
+			ImmediateFirstRetry(),
+		) {
+			delays = append(delays, attempt.Delay)
+		}
+
+		if len(delays) != 3 {
+			t.Fatalf("expected 3 attempts, got %d", len(delays))
+		}
+		if delays[1] != 0 {
+			t.Errorf("second attempt should have no delay, got %v", delays[1])
+		}
+		if delays[2] != 10*time.Millisecond {
+			t.Errorf("third attempt should have 10ms delay, got %v", delays[2])
+		}
+	})
+
 	t.Run("early exit", func(t *testing.T) {
 		attempts := 0
 
@@ -70,6 +101,203 @@ func TestAttempts(t *testing.T) {
 	})
 }
 
+func TestAttemptErrors(t *testing.T) {
+	t.Run("history matches attempt count", func(t *testing.T) {
+		var last *Attempt
+		for attempt := range Attempts(Tries(5), Initial(time.Millisecond)) {
+			if len(attempt.Errors) != attempt.Number-1 {
+				t.Errorf("attempt %d: expected %d errors, got %d", attempt.Number, attempt.Number-1, len(attempt.Errors))
+			}
+			attempt.LastError = fmt.Errorf("failure %d", attempt.Number)
+			last = attempt
+		}
+
+		all := last.AllErrors()
+		if len(all) != 5 {
+			t.Fatalf("expected 5 errors, got %d", len(all))
+		}
+		for i, err := range all {
+			if want := fmt.Sprintf("failure %d", i+1); err.Error() != want {
+				t.Errorf("error %d: expected %q, got %q", i, want, err.Error())
+			}
+		}
+	})
+
+	t.Run("attempts without errors are not recorded", func(t *testing.T) {
+		for attempt := range Attempts(Tries(3), Initial(time.Millisecond)) {
+			if attempt.Number == 2 {
+				attempt.LastError = errors.New("only failure")
+			}
+			if attempt.Number == 3 && len(attempt.Errors) != 1 {
+				t.Errorf("expected 1 recorded error, got %d", len(attempt.Errors))
+			}
+		}
+	})
+
+	t.Run("history is bounded", func(t *testing.T) {
+		var last *Attempt
+		for attempt := range Attempts(Tries(maxErrorHistory+20), Initial(time.Nanosecond), Max(time.Nanosecond)) {
+			attempt.LastError = fmt.Errorf("failure %d", attempt.Number)
+			last = attempt
+		}
+
+		if len(last.Errors) != maxErrorHistory {
+			t.Fatalf("expected %d errors, got %d", maxErrorHistory, len(last.Errors))
+		}
+		if got := last.Errors[0].Error(); got != "failure 20" {
+			t.Errorf("expected oldest kept error 'failure 20', got %q", got)
+		}
+	})
+}
+
+func TestAttemptRemainingTries(t *testing.T) {
+	var remaining []int
+	for attempt := range Attempts(Tries(4), Initial(time.Millisecond), Max(3*time.Millisecond)) {
+		if attempt.MaxTries != 4 || attempt.MaxInterval != 3*time.Millisecond {
+			t.Errorf("attempt %d: expected MaxTries 4 and MaxInterval 3ms, got %d and %v", attempt.Number, attempt.MaxTries, attempt.MaxInterval)
+		}
+		remaining = append(remaining, attempt.RemainingTries())
+	}
+	if want := []int{3, 2, 1, 0}; !slices.Equal(remaining, want) {
+		t.Errorf("expected remaining tries %v, got %v", want, remaining)
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		for attempt := range Attempts(Forever(), MaxTime(time.Second)) {
+			if got := attempt.RemainingTries(); got != -1 {
+				t.Errorf("expected -1 without a limit, got %d", got)
+			}
+			break
+		}
+	})
+}
+
+func TestAttemptElapsed(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"NoDelay", []Option{NoDelay(), Tries(3)}},
+		{"ImmediateFirstRetry", []Option{ImmediateFirstRetry(), Initial(time.Millisecond), Tries(3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var elapsed []time.Duration
+			for attempt := range Attempts(tt.opts...) {
+				elapsed = append(elapsed, attempt.Elapsed)
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			if len(elapsed) != 3 || elapsed[0] != 0 {
+				t.Fatalf("expected 3 attempts starting at 0, got %v", elapsed)
+			}
+			for i := 1; i < len(elapsed); i++ {
+				if elapsed[i] < time.Duration(i)*20*time.Millisecond {
+					t.Errorf("attempt %d: expected at least %v elapsed, got %v", i+1, time.Duration(i)*20*time.Millisecond, elapsed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAttemptFormatting(t *testing.T) {
+	attempt := &Attempt{Number: 3, Delay: 1500 * time.Millisecond, Elapsed: 4 * time.Second}
+
+	if got, want := fmt.Sprintf("%v", attempt), "attempt 3 (delay 1.5s, elapsed 4s)"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	attempt.LastError = errors.New("connection refused")
+	if got, want := attempt.String(), "attempt 3 (delay 1.5s, elapsed 4s, last error: connection refused)"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("retrying", "attempt", attempt)
+	for _, want := range []string{
+		"attempt.number=3",
+		"attempt.delay=1.5s",
+		"attempt.elapsed=4s",
+		`attempt.error="connection refused"`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected log to contain %q, got: %s", want, buf.String())
+		}
+	}
+}
+
+func TestAttemptsSafetyLimit(t *testing.T) {
+	original := DefaultMaxAttempts
+	defer func() { DefaultMaxAttempts = original }()
+	DefaultMaxAttempts = 4
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	unbounded := []Option{Forever(), MaxTime(0), Initial(time.Millisecond), NoJitter()}
+
+	t.Run("context iterator trips guard", func(t *testing.T) {
+		attempts := 0
+		for range AttemptsWithContext(context.Background(), unbounded...) {
+			attempts++
+		}
+		if attempts != 4 {
+			t.Errorf("expected 4 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("DoWithAttempts reports max attempts", func(t *testing.T) {
+		attempts := 0
+		err := DoWithAttempts(func(*Attempt) error {
+			attempts++
+			return errors.New("always fail")
+		}, unbounded...)
+
+		if !errors.Is(err, ErrMaxAttempts) {
+			t.Errorf("expected ErrMaxAttempts, got %v", err)
+		}
+		if attempts != 4 {
+			t.Errorf("expected 4 attempts, got %d", attempts)
+		}
+	})
+}
+
+func TestAttemptsIndexed(t *testing.T) {
+	count := 0
+	var delays []time.Duration
+	for i, attempt := range AttemptsIndexed(Tries(4), Initial(time.Millisecond), NoJitter()) {
+		if i != attempt.Number-1 {
+			t.Errorf("index %d does not match attempt %d", i, attempt.Number)
+		}
+		delays = append(delays, attempt.Delay)
+		attempt.LastError = errors.New("temporary")
+		count++
+	}
+
+	if count != 4 {
+		t.Errorf("expected 4 attempts, got %d", count)
+	}
+	want := []time.Duration{0, time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}
+	if !slices.Equal(delays, want) {
+		t.Errorf("expected the Attempts backoff %v, got %v", want, delays)
+	}
+
+	t.Run("break stops the iteration", func(t *testing.T) {
+		last := -1
+		for i := range AttemptsIndexed(Tries(5), Initial(time.Millisecond)) {
+			last = i
+			if i == 1 {
+				break
+			}
+		}
+		if last != 1 {
+			t.Errorf("expected to stop at index 1, got %d", last)
+		}
+	})
+}
+
 func TestAttemptsWithContext(t *testing.T) {
 	t.Run("context cancellation", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -96,6 +324,28 @@ func TestAttemptsWithContext(t *testing.T) {
 		}
 	})
 
+	t.Run("cancel inside loop body", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		attempts, sleeps := 0, 0
+		start := time.Now()
+		for range AttemptsWithContext(ctx, Initial(time.Second), Tries(5), WithSleepHook(func() { sleeps++ }, nil)) {
+			attempts++
+			cancel()
+		}
+
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+		if sleeps != 0 {
+			t.Errorf("expected no sleeps after cancellation, got %d", sleeps)
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("expected to stop promptly, took %v", elapsed)
+		}
+	})
+
 	t.Run("context in attempt", func(t *testing.T) {
 		type contextKey string
 		ctx := context.WithValue(context.Background(), contextKey("key"), "value")
@@ -108,6 +358,41 @@ func TestAttemptsWithContext(t *testing.T) {
 			break
 		}
 	})
+
+	t.Run("stops at context deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		attempts := 0
+		for range AttemptsWithContext(ctx, Initial(time.Second), MaxTime(time.Minute), NoJitter()) {
+			attempts++
+		}
+		elapsed := time.Since(start)
+
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+		if elapsed < 90*time.Millisecond || elapsed > 500*time.Millisecond {
+			t.Errorf("expected loop to end near the 100ms deadline, took %v", elapsed)
+		}
+	})
+
+	t.Run("trims last sleep to max elapsed time", func(t *testing.T) {
+		start := time.Now()
+		attempts := 0
+		for range AttemptsWithContext(context.Background(), Initial(time.Second), MaxTime(100*time.Millisecond), NoJitter()) {
+			attempts++
+		}
+		elapsed := time.Since(start)
+
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+		if elapsed < 90*time.Millisecond || elapsed > 500*time.Millisecond {
+			t.Errorf("expected loop to end near the 100ms limit, took %v", elapsed)
+		}
+	})
 }
 
 func TestDoWithAttempts(t *testing.T) {
@@ -142,12 +427,85 @@ func TestDoWithAttempts(t *testing.T) {
 		if err == nil {
 			t.Error("expected error, got nil")
 		}
+		if !errors.Is(err, ErrMaxAttempts) {
+			t.Errorf("expected ErrMaxAttempts, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "persistent error") {
+			t.Errorf("expected last error in message, got: %v", err)
+		}
 
 		if attempts != 3 {
 			t.Errorf("expected 3 attempts, got %d", attempts)
 		}
 	})
 
+	t.Run("stops on max elapsed time", func(t *testing.T) {
+		attempts := 0
+		lastErr := errors.New("slow failure")
+
+		start := time.Now()
+		err := DoWithAttempts(func(attempt *Attempt) error {
+			attempts++
+			time.Sleep(30 * time.Millisecond)
+			return lastErr
+		}, Forever(), MaxTime(50*time.Millisecond), Initial(5*time.Millisecond), NoJitter())
+		elapsed := time.Since(start)
+
+		if !errors.Is(err, ErrMaxElapsed) {
+			t.Errorf("expected ErrMaxElapsed, got: %v", err)
+		}
+		if !errors.Is(err, lastErr) {
+			t.Errorf("expected last error to be wrapped, got: %v", err)
+		}
+		// Time spent in fn counts towards MaxTime
+		if attempts > 2 {
+			t.Errorf("expected at most 2 attempts, got %d", attempts)
+		}
+		if elapsed > 200*time.Millisecond {
+			t.Errorf("expected to stop soon after 50ms, took %v", elapsed)
+		}
+	})
+
+	t.Run("last error passed to next attempt", func(t *testing.T) {
+		var seen []error
+		first := errors.New("first")
+
+		_ = DoWithAttempts(func(attempt *Attempt) error {
+			seen = append(seen, attempt.LastError)
+			return first
+		}, Tries(2), Initial(time.Millisecond))
+
+		if len(seen) != 2 || seen[0] != nil || !errors.Is(seen[1], first) {
+			t.Errorf("expected LastError [nil first], got %v", seen)
+		}
+	})
+
+	t.Run("AllErrors does not repeat the last error", func(t *testing.T) {
+		var all []error
+		_ = DoWithAttempts(func(attempt *Attempt) error {
+			all = attempt.AllErrors()
+			return fmt.Errorf("failure %d", attempt.Number)
+		}, Tries(3), Initial(time.Millisecond))
+
+		if len(all) != 2 || all[0].Error() != "failure 1" || all[1].Error() != "failure 2" {
+			t.Errorf("expected [failure 1 failure 2], got %v", all)
+		}
+	})
+
+	t.Run("previous errors passed to next attempt", func(t *testing.T) {
+		var seen [][]error
+		first := errors.New("first")
+
+		_ = DoWithAttempts(func(attempt *Attempt) error {
+			seen = append(seen, attempt.Errors)
+			return first
+		}, Tries(2), Initial(time.Millisecond))
+
+		if len(seen) != 2 || len(seen[0]) != 0 || len(seen[1]) != 1 || !errors.Is(seen[1][0], first) {
+			t.Errorf("expected errors [[] [first]], got %v", seen)
+		}
+	})
+
 	t.Run("permanent error", func(t *testing.T) {
 		attempts := 0
 		permErr := errors.New("permanent error")
@@ -167,6 +525,68 @@ func TestDoWithAttempts(t *testing.T) {
 	})
 }
 
+func TestDoWithAttemptsNoAttempts(t *testing.T) {
+	t.Run("pre-cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		calls := 0
+		err := DoWithAttemptsContext(ctx, func(*Attempt) error {
+			calls++
+			return nil
+		})
+
+		if !errors.Is(err, ErrNoAttempts) || !errors.Is(err, context.Canceled) {
+			t.Errorf("expected ErrNoAttempts wrapping context.Canceled, got %v", err)
+		}
+		if calls != 0 {
+			t.Errorf("expected no calls, got %d", calls)
+		}
+	})
+
+	t.Run("expired deadline", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		err := DoWithAttemptsContext(ctx, func(*Attempt) error {
+			t.Error("fn should not be called")
+			return nil
+		})
+
+		if !errors.Is(err, ErrNoAttempts) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected ErrNoAttempts wrapping context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Tries(0) is unlimited, not zero", func(t *testing.T) {
+		calls := 0
+		err := DoWithAttempts(func(*Attempt) error {
+			calls++
+			if calls < 3 {
+				return errors.New("temporary")
+			}
+			return nil
+		}, Tries(0), Initial(time.Millisecond))
+
+		if err != nil {
+			t.Errorf("expected success, got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("failed attempts are not ErrNoAttempts", func(t *testing.T) {
+		err := DoWithAttempts(func(*Attempt) error {
+			return errors.New("always fail")
+		}, Tries(2), Initial(time.Millisecond))
+
+		if errors.Is(err, ErrNoAttempts) {
+			t.Errorf("expected no ErrNoAttempts after real attempts, got %v", err)
+		}
+	})
+}
+
 func TestDoWithAttemptsContext(t *testing.T) {
 	t.Run("context cancellation", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -0,0 +1,138 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"time"
+)
+
+// RetryLogger receives events from a retry loop.
+// Set it with WithLogger; Retry, the iterators, DoWithAttempts and
+// RetryMiddleware all report to it.
+type RetryLogger interface {
+	// LogAttempt is called after every attempt with its result (nil on success,
+	// including an attempt that returned ErrStop).
+	LogAttempt(attempt int, err error)
+	// LogRetry is called before waiting delay for the given attempt.
+	LogRetry(attempt int, delay time.Duration)
+	// LogGiveUp is called when retrying stops without success.
+	LogGiveUp(attempts int, err error)
+}
+
+// stdLogger adapts a *log.Logger to RetryLogger
+type stdLogger struct {
+	logger *log.Logger
+}
+
+// StdLogger returns a RetryLogger that writes to a standard library *log.Logger.
+//
+// Example:
+//
+//	logger := log.New(os.Stderr, "[RETRY] ", log.LstdFlags)
+//	err := ebo.Retry(fn, ebo.WithLogger(ebo.StdLogger(logger)))
+func StdLogger(logger *log.Logger) RetryLogger {
+	return &stdLogger{logger: logger}
+}
+
+func (l *stdLogger) LogAttempt(attempt int, err error) {
+	if err != nil {
+		l.logger.Printf("Attempt %d failed: %v", attempt, err)
+		return
+	}
+	l.logger.Printf("Attempt %d succeeded", attempt)
+}
+
+func (l *stdLogger) LogRetry(attempt int, delay time.Duration) {
+	l.logger.Printf("Retrying in %v (attempt %d)", delay, attempt)
+}
+
+func (l *stdLogger) LogGiveUp(attempts int, err error) {
+	l.logger.Printf("Giving up after %d attempts: %v", attempts, err)
+}
+
+// slogLogger adapts a *slog.Logger to RetryLogger
+type slogLogger struct {
+	logger        *slog.Logger
+	escalateAfter int // Retries logged below Warn before escalating (0 disables)
+}
+
+// SlogLogger returns a RetryLogger that writes structured records to a *slog.Logger.
+// Failed attempts are logged at Warn, give-ups at Error and the rest at Debug.
+// With EscalateAfter, the first failures are logged at Info instead.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.WithLogger(ebo.SlogLogger(slog.Default())))
+func SlogLogger(logger *slog.Logger) RetryLogger {
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) LogAttempt(attempt int, err error) {
+	if err != nil {
+		l.logger.Log(context.Background(), l.level(attempt, slog.LevelInfo, slog.LevelWarn),
+			"retry attempt failed", "attempt", attempt, "error", err)
+		return
+	}
+	l.logger.Debug("retry attempt succeeded", "attempt", attempt)
+}
+
+func (l *slogLogger) LogRetry(attempt int, delay time.Duration) {
+	l.logger.Log(context.Background(), l.level(attempt-1, slog.LevelDebug, slog.LevelDebug),
+		"retrying", "attempt", attempt, "delay", delay)
+}
+
+// level returns quiet for the first escalateAfter failures and Warn after
+// them. Without escalation it returns unescalated.
+func (l *slogLogger) level(failures int, quiet, unescalated slog.Level) slog.Level {
+	switch {
+	case l.escalateAfter <= 0:
+		return unescalated
+	case failures <= l.escalateAfter:
+		return quiet
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// escalate returns a copy of l that escalates after n failures.
+func (l *slogLogger) escalate(n int) RetryLogger {
+	return &slogLogger{logger: l.logger, escalateAfter: n}
+}
+
+func (l *slogLogger) LogGiveUp(attempts int, err error) {
+	l.logger.Error("retry gave up", "attempts", attempts, "error", err)
+}
+
+// escalatingLogger is implemented by loggers that support EscalateAfter.
+type escalatingLogger interface {
+	escalate(n int) RetryLogger
+}
+
+// logAttempt reports an attempt result to the configured logger, if any.
+// ErrStop ends the loop successfully, so it is reported as a success.
+func (c *RetryConfig) logAttempt(attempt int, err error) {
+	if c.logger == nil {
+		return
+	}
+	if errors.Is(err, ErrStop) {
+		err = nil
+	}
+	c.logger.LogAttempt(attempt, err)
+}
+
+// logRetry reports an upcoming retry to the configured logger, if any.
+func (c *RetryConfig) logRetry(attempt int, delay time.Duration) {
+	if c.logger != nil {
+		c.logger.LogRetry(attempt, delay)
+	}
+}
+
+// giveUp reports a failed retry loop to the configured logger and returns reason and err.
+func (c *RetryConfig) giveUp(attempts int, reason StopReason, err error) (StopReason, error) {
+	if c.logger != nil {
+		c.logger.LogGiveUp(attempts, err)
+	}
+	return reason, err
+}
@@ -0,0 +1,233 @@
+package ebo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureLogger records retry events for assertions
+type captureLogger struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *captureLogger) record(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) LogAttempt(attempt int, err error) {
+	l.record("attempt %d: %v", attempt, err)
+}
+
+func (l *captureLogger) LogRetry(attempt int, _ time.Duration) {
+	l.record("retry %d", attempt)
+}
+
+func (l *captureLogger) LogGiveUp(attempts int, err error) {
+	l.record("give up %d: %v", attempts, err)
+}
+
+func failTwice() func() error {
+	attempts := 0
+	return func() error {
+		attempts++
+		if attempts <= 2 {
+			return errors.New("fail")
+		}
+		return nil
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	successSequence := []string{
+		"attempt 1: fail",
+		"retry 2",
+		"attempt 2: fail",
+		"retry 3",
+		"attempt 3: <nil>",
+	}
+
+	t.Run("Retry success", func(t *testing.T) {
+		logger := &captureLogger{}
+		fn := failTwice()
+
+		err := Retry(fn, WithLogger(logger), Initial(time.Millisecond))
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		if !reflect.DeepEqual(logger.events, successSequence) {
+			t.Errorf("unexpected events:\n got: %q\nwant: %q", logger.events, successSequence)
+		}
+	})
+
+	t.Run("Retry give up", func(t *testing.T) {
+		logger := &captureLogger{}
+
+		_ = Retry(func() error {
+			return errors.New("fail")
+		}, WithLogger(logger), Initial(time.Millisecond), Tries(2))
+
+		want := []string{
+			"attempt 1: fail",
+			"retry 2",
+			"attempt 2: fail",
+			"give up 2: fail",
+		}
+		if !reflect.DeepEqual(logger.events, want) {
+			t.Errorf("unexpected events:\n got: %q\nwant: %q", logger.events, want)
+		}
+	})
+
+	t.Run("DoWithAttempts", func(t *testing.T) {
+		logger := &captureLogger{}
+		fn := failTwice()
+
+		err := DoWithAttempts(func(*Attempt) error {
+			return fn()
+		}, WithLogger(logger), Initial(time.Millisecond))
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		if !reflect.DeepEqual(logger.events, successSequence) {
+			t.Errorf("unexpected events:\n got: %q\nwant: %q", logger.events, successSequence)
+		}
+	})
+
+	t.Run("ErrStop is a success", func(t *testing.T) {
+		want := []string{"attempt 1: <nil>"}
+
+		logger := &captureLogger{}
+		_ = Retry(func() error { return ErrStop }, WithLogger(logger))
+		if !reflect.DeepEqual(logger.events, want) {
+			t.Errorf("Retry: unexpected events:\n got: %q\nwant: %q", logger.events, want)
+		}
+
+		logger = &captureLogger{}
+		_ = DoWithAttempts(func(*Attempt) error { return ErrStop }, WithLogger(logger))
+		if !reflect.DeepEqual(logger.events, want) {
+			t.Errorf("DoWithAttempts: unexpected events:\n got: %q\nwant: %q", logger.events, want)
+		}
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		logger := &captureLogger{}
+		attempts := 0
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts <= 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+
+		middleware := NewRetryMiddleware(handler, DefaultResponseChecker,
+			WithLogger(logger), Initial(time.Millisecond))
+		middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		want := []string{
+			"attempt 1: retryable status: 500",
+			"retry 2",
+			"attempt 2: retryable status: 500",
+			"retry 3",
+			"attempt 3: <nil>",
+		}
+		if !reflect.DeepEqual(logger.events, want) {
+			t.Errorf("unexpected events:\n got: %q\nwant: %q", logger.events, want)
+		}
+	})
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := StdLogger(log.New(&buf, "", 0))
+
+	_ = Retry(func() error {
+		return errors.New("boom")
+	}, WithLogger(logger), Initial(time.Millisecond), NoJitter(), Tries(2))
+
+	logs := buf.String()
+	for _, want := range []string{
+		"Attempt 1 failed: boom",
+		"Retrying in 1ms (attempt 2)",
+		"Giving up after 2 attempts: boom",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("expected log %q, got: %s", want, logs)
+		}
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := SlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	_ = Retry(func() error {
+		return errors.New("boom")
+	}, WithLogger(logger), Initial(time.Millisecond), Tries(2))
+
+	logs := buf.String()
+	for _, want := range []string{
+		`level=WARN msg="retry attempt failed" attempt=1 error=boom`,
+		`level=DEBUG msg=retrying attempt=2`,
+		`level=ERROR msg="retry gave up" attempts=2 error=boom`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("expected log %q, got: %s", want, logs)
+		}
+	}
+}
+
+func TestEscalateAfter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := SlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	// EscalateAfter comes first to check it does not depend on option order
+	_ = Retry(func() error {
+		return errors.New("boom")
+	}, EscalateAfter(2), WithLogger(logger), Initial(time.Millisecond), Tries(4))
+
+	logs := buf.String()
+	for _, want := range []string{
+		`level=INFO msg="retry attempt failed" attempt=1 error=boom`,
+		`level=DEBUG msg=retrying attempt=2`,
+		`level=INFO msg="retry attempt failed" attempt=2 error=boom`,
+		`level=DEBUG msg=retrying attempt=3`,
+		`level=WARN msg="retry attempt failed" attempt=3 error=boom`,
+		`level=WARN msg=retrying attempt=4`,
+		`level=WARN msg="retry attempt failed" attempt=4 error=boom`,
+		`level=ERROR msg="retry gave up" attempts=4 error=boom`,
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("expected log %q, got: %s", want, logs)
+		}
+	}
+
+	// Below Warn the first blips are dropped entirely
+	buf.Reset()
+	quiet := SlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	attempts := 0
+	_ = Retry(func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("blip")
+		}
+		return nil
+	}, WithLogger(quiet), EscalateAfter(2), Initial(time.Millisecond), Tries(4))
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no logs at Warn for recovered blips, got: %s", buf.String())
+	}
+}
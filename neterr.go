@@ -0,0 +1,77 @@
+package ebo
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// IsRetryableNetErr reports whether err is a transport-level error that is
+// likely transient, such as a refused or reset connection, a timeout, a
+// temporary DNS failure or a connection closed mid-response. Errors it does
+// not recognize, context errors, certificate errors and protocol mismatches
+// (for example an unsupported URL scheme) are reported as not retryable.
+//
+// HTTPRetryTransport and NewHTTPClient use it to decide which transport
+// errors to retry; WithNetErrorClassifier replaces it.
+//
+// Example:
+//
+//	err := ebo.RetryWithCondition(func() error {
+//	    return publish(conn, msg)
+//	}, ebo.IsRetryableNetErr, ebo.Tries(5))
+func IsRetryableNetErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Certificate problems and talking TLS to a plain server never fix themselves
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalidCert      x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		recordHeader     tls.RecordHeaderError
+	)
+	if errors.As(err, &unknownAuthority) || errors.As(err, &invalidCert) ||
+		errors.As(err, &hostname) || errors.As(err, &recordHeader) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ETIMEDOUT) || errors.Is(err, syscall.EHOSTUNREACH) ||
+		errors.Is(err, syscall.ENETUNREACH) {
+		return true
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// netErrRetryable returns the configured transport error classifier or IsRetryableNetErr.
+func (c *RetryConfig) netErrRetryable() func(error) bool {
+	if c.netErrClassifier == nil {
+		return IsRetryableNetErr
+	}
+	return c.netErrClassifier
+}
@@ -1,12 +1,19 @@
 package ebo
 
-import "time"
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
 
 // Option is a function that configures a RetryConfig
 type Option func(*RetryConfig)
 
 // Initial sets the initial retry interval.
 // This is the delay before the first retry attempt.
+// A zero or negative interval is treated as 1ms to avoid busy loops;
+// use ImmediateFirstRetry to retry once without waiting.
 //
 // Example:
 //
@@ -29,8 +36,22 @@ func Max(d time.Duration) Option {
 	}
 }
 
+// Min sets the minimum retry interval.
+// The base delay between retries will not drop below this value; jitter may
+// still shorten an individual delay by up to the jitter factor.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.Min(100*time.Millisecond))
+func Min(d time.Duration) Option {
+	return func(c *RetryConfig) {
+		c.MinInterval = d
+	}
+}
+
 // MaxTime sets the maximum total time for all retries.
 // The retry process will stop after this duration, regardless of the number of attempts.
+// At least one attempt is always made, even if d has passed before it starts.
 //
 // Example:
 //
@@ -54,7 +75,8 @@ func Tries(n int) Option {
 }
 
 // Multiplier sets the backoff multiplier.
-// Each retry interval is multiplied by this factor.
+// Each retry interval is multiplied by this factor. Values <= 0 are treated
+// as 1, keeping the interval constant instead of collapsing it to zero.
 //
 // Example:
 //
@@ -65,6 +87,24 @@ func Multiplier(f float64) Option {
 	}
 }
 
+// Multipliers grows the interval by a different factor at every retry: the
+// delay before the second attempt is Initial, and each later delay is the
+// previous one times the next factor. factors[i] thus yields the delay before
+// attempt i+3, not i+2: like Multiplier, no factor applies to Initial itself.
+// Once the factors run out, the last one is repeated.
+// Max, Min and jitter apply as with Multiplier, which this option replaces;
+// factors <= 0 are treated as 1. Multipliers(m) is the same as Multiplier(m).
+//
+// Example:
+//
+//	// Back off hard at first, then gently: 100ms, 400ms, 1.6s, 2.4s, 3.6s, ...
+//	err := ebo.Retry(fn, ebo.Initial(100*time.Millisecond), ebo.Multipliers(4, 4, 1.5))
+func Multipliers(factors ...float64) Option {
+	return func(c *RetryConfig) {
+		c.multipliers = factors
+	}
+}
+
 // Jitter sets the randomization factor for jitter (0-1).
 // Adds randomness to prevent thundering herd problem.
 //
@@ -77,6 +117,71 @@ func Jitter(f float64) Option {
 	}
 }
 
+// WithDeterministicJitter draws jitter from a source seeded with seed,
+// restarted for every retry loop, so Retry, the iterators and every call of a
+// Retrier produce the same delay sequence for the same seed. It is meant for
+// tests that assert exact delays; production code should keep the default
+// source so that clients do not retry in lockstep.
+//
+// Example:
+//
+//	for attempt := range ebo.Attempts(ebo.Tries(5), ebo.WithDeterministicJitter(42)) {
+//	    delays = append(delays, attempt.Delay)
+//	}
+func WithDeterministicJitter(seed int64) Option {
+	return func(c *RetryConfig) {
+		c.seed = &seed
+	}
+}
+
+// JitterSeedFunc makes HTTPRetryTransport, HTTPDo and RetryMiddleware draw
+// the jitter of each request's retries from a source seeded with seed(req).
+// Deriving the seed from the host and a per-process nonce keeps clients
+// desynchronized from each other while retries to the same host follow the
+// same, reproducible schedule.
+//
+// Example:
+//
+//	nonce := rand.Int63()
+//	client := ebo.NewHTTPClient(ebo.API(), ebo.JitterSeedFunc(func(req *http.Request) int64 {
+//	    h := fnv.New64a()
+//	    h.Write([]byte(req.URL.Host))
+//	    return int64(h.Sum64()) ^ nonce
+//	}))
+func JitterSeedFunc(seed func(req *http.Request) int64) Option {
+	return func(c *RetryConfig) {
+		c.requestSeed = seed
+	}
+}
+
+// JitterCap randomizes the maximum interval itself within Max*(1±f), once per
+// retry loop. Clients that all reach the Max ceiling would otherwise retry in
+// lockstep; with JitterCap each one settles on a slightly different ceiling.
+// It composes with Jitter, which still applies to every delay.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.Max(30*time.Second), ebo.JitterCap(0.1)) // ceiling between 27s and 33s
+func JitterCap(f float64) Option {
+	return func(c *RetryConfig) {
+		c.capJitter = f
+	}
+}
+
+// MaxJitter caps how far jitter may move a delay, in either direction,
+// whatever the interval: with Jitter(0.3) a 60s interval would otherwise vary
+// by up to 18s. The fractional range from Jitter is computed first and then
+// narrowed to ±d.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.Max(time.Minute), ebo.Jitter(0.3), ebo.MaxJitter(2*time.Second))
+func MaxJitter(d time.Duration) Option {
+	return func(c *RetryConfig) {
+		c.maxJitter = d
+	}
+}
+
 // NoJitter disables jitter completely.
 // Useful for predictable testing or when exact timing is required.
 //
@@ -89,8 +194,118 @@ func NoJitter() Option {
 	}
 }
 
+// WithStopChan aborts retrying when stop is closed or receives a value, for
+// example to shut down gracefully where no context is available. A backoff
+// sleep in progress is interrupted, no further attempt is started, and Retry
+// returns the last error wrapped with ErrAborted (ReasonAborted). An attempt
+// that is already running is not interrupted.
+//
+// Example:
+//
+//	shutdown := make(chan struct{})
+//	go func() {
+//	    <-sigterm
+//	    close(shutdown)
+//	}()
+//
+//	err := ebo.Retry(syncInventory, ebo.Forever(), ebo.WithStopChan(shutdown))
+//	if errors.Is(err, ebo.ErrAborted) {
+//	    log.Println("shutting down before inventory synced")
+//	}
+func WithStopChan(stop <-chan struct{}) Option {
+	return func(c *RetryConfig) {
+		c.stop = stop
+	}
+}
+
+// WithClock makes the retry loops sleep on clock's timers instead of real
+// ones, so tests can step through the backoff of Retry, the iterators and
+// RetryMiddleware without waiting. Time-based limits such as MaxTime still
+// use the real time.
+//
+// Example:
+//
+//	// fake.After returns a channel the test sends on to end each backoff sleep
+//	mw := ebo.Middleware(ebo.DefaultResponseChecker, ebo.Tries(3), ebo.WithClock(fake))
+func WithClock(clock Clock) Option {
+	return func(c *RetryConfig) {
+		c.clock = clock
+	}
+}
+
+// WithPause lets a worker be paused administratively: between attempts, after
+// the backoff sleep, retrying blocks while isPaused reports true, checking it
+// every poll. The time spent paused counts toward neither MaxTime nor a
+// WithTimeBudget, so the loop resumes where it left off. Cancelling the
+// context or WithStopChan still ends it while paused. A poll <= 0 means 100ms.
+//
+// Example:
+//
+//	var paused atomic.Bool // Toggled by an admin endpoint
+//
+//	err := ebo.Retry(syncOrders, ebo.MaxTime(10*time.Minute), ebo.WithPause(paused.Load, time.Second))
+func WithPause(isPaused func() bool, poll time.Duration) Option {
+	if poll <= 0 {
+		poll = defaultPausePoll
+	}
+	return func(c *RetryConfig) {
+		c.isPaused = isPaused
+		c.pollPaused = poll
+	}
+}
+
+// FailOnBackoffLimit makes retrying give up, instead of waiting for a slot,
+// when every backoff slot allowed by SetMaxConcurrentBackoffs is taken. The
+// last error is then returned wrapped with ErrBackoffLimit (ReasonBackoffLimit),
+// shedding load early during a wide outage.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.API(), ebo.FailOnBackoffLimit())
+//	if errors.Is(err, ebo.ErrBackoffLimit) {
+//	    return errOverloaded
+//	}
+func FailOnBackoffLimit() Option {
+	return func(c *RetryConfig) {
+		c.failOnBackoffLimit = true
+	}
+}
+
+// MaxCumulativeDelay caps the total time spent sleeping between attempts:
+// no attempt is scheduled whose backoff would bring the sum of slept
+// intervals above d, however many attempts that allows. Unlike MaxTime, the
+// time spent in the retried function does not count. Stopping for this reason
+// is reported as ReasonMaxElapsed.
+//
+// Example:
+//
+//	// Sleep at most 10s in total across all retries
+//	err := ebo.Retry(fn, ebo.Forever(), ebo.MaxTime(0), ebo.MaxCumulativeDelay(10*time.Second))
+func MaxCumulativeDelay(d time.Duration) Option {
+	return func(c *RetryConfig) {
+		c.maxCumulativeDelay = d
+	}
+}
+
+// ReachMaxBy sets Multiplier so that the interval grows from Initial to Max
+// over n retry intervals: the first retry waits Initial and the n-th waits Max
+// (before jitter). It is computed after all options are applied, so it works
+// with Initial and Max given in any order and overrides Multiplier. Values of
+// n below 2, or a Max not above Initial, leave Multiplier unchanged.
+//
+// Example:
+//
+//	// 100ms, 316ms, 1s, 3.16s, 10s, 10s, ...
+//	err := ebo.Retry(fn, ebo.Initial(100*time.Millisecond), ebo.Max(10*time.Second), ebo.ReachMaxBy(5))
+func ReachMaxBy(n int) Option {
+	return func(c *RetryConfig) {
+		c.reachMaxBy = n
+	}
+}
+
 // Forever sets no retry limit (only time-based stopping).
 // Use with MaxTime to retry continuously for a specific duration.
+// Without MaxTime, retrying stops after DefaultMaxAttempts attempts.
 //
 // Example:
 //
@@ -147,6 +362,29 @@ func Gentle() Option {
 	}
 }
 
+// Poll sets a constant interval with light jitter for polling loops.
+// It retries until success or MaxTime (the default or the caller's) runs out.
+//
+// Configuration:
+// - Initial: interval
+// - Max: interval + 10%, leaving room for jitter
+// - Retries: unlimited
+// - Multiplier: 1.0
+// - Jitter: 0.1
+//
+// Example:
+//
+//	err := ebo.Retry(checkJobDone, ebo.Poll(2*time.Second), ebo.MaxTime(time.Minute))
+func Poll(interval time.Duration) Option {
+	return func(c *RetryConfig) {
+		c.InitialInterval = interval
+		c.MaxInterval = interval + interval/10
+		c.MaxRetries = 0
+		c.Multiplier = 1.0
+		c.RandomizeFactor = 0.1
+	}
+}
+
 // Linear disables exponential backoff (constant interval).
 // Each retry uses the same interval as the previous one.
 //
@@ -279,3 +517,585 @@ func Timeout(d time.Duration) Option {
 		c.MaxRetries = 0 // No retry limit, only time
 	}
 }
+
+// WithChecker sets the ResponseChecker used by HTTPDo and HTTPRetryTransport
+// to decide which responses are retried. Defaults to DefaultResponseChecker.
+//
+// Example:
+//
+//	resp, err := ebo.HTTPDo(req, nil, ebo.API(), ebo.WithChecker(ebo.RetryAfterChecker))
+func WithChecker(checker ResponseChecker) Option {
+	return func(c *RetryConfig) {
+		c.checker = checker
+	}
+}
+
+// StatusBackoff computes the delay after a retryable HTTP response whose
+// status is in overrides with that status's option applied over the others,
+// so different statuses can back off differently. Only the delay is
+// affected: limits such as Tries still come from the other options, and a
+// Retry-After header or RetryDecision.After still takes precedence. It applies
+// to HTTPDo, HTTPRetryTransport and RetryMiddleware.
+//
+// Example:
+//
+//	resp, err := ebo.HTTPDo(req, nil, ebo.API(), ebo.Tries(6), ebo.StatusBackoff(map[int]ebo.Option{
+//	    http.StatusTooManyRequests:    ebo.Initial(5 * time.Second), // Back off gently but long
+//	    http.StatusServiceUnavailable: ebo.Initial(50 * time.Millisecond),
+//	}))
+func StatusBackoff(overrides map[int]Option) Option {
+	return func(c *RetryConfig) {
+		c.statusBackoff = overrides
+	}
+}
+
+// WithDelayChecker makes HTTPDo, HTTPRetryTransport and RetryMiddleware
+// decide with checker in place of their ResponseChecker. When its decision
+// has After > 0, that wait, capped at Max, replaces the backoff and any
+// Retry-After header before the next attempt. RetryOnJSONField still applies.
+//
+// Example:
+//
+//	checker := func(resp *http.Response) ebo.RetryDecision {
+//	    if resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("X-Warming-Up") != "" {
+//	        return ebo.RetryDecision{Retry: true, After: 2 * time.Second}
+//	    }
+//	    return ebo.RetryDecision{Retry: ebo.DefaultResponseChecker(resp)}
+//	}
+//	resp, err := ebo.HTTPDo(req, nil, ebo.API(), ebo.WithDelayChecker(checker))
+func WithDelayChecker(checker CheckerWithDelay) Option {
+	return func(c *RetryConfig) {
+		c.delayChecker = checker
+	}
+}
+
+// WithContext sets a context that cancels the retry loop.
+// Retry stops before the next attempt, or while waiting between attempts,
+// once the context is done and returns the context error.
+//
+// Example:
+//
+//	errCh := ebo.RetryAsync(fn, ebo.WithContext(ctx), ebo.Tries(5))
+func WithContext(ctx context.Context) Option {
+	return func(c *RetryConfig) {
+		c.ctx = ctx
+	}
+}
+
+// WithConcurrency bounds the number of items the batch APIs, such as RetryEach,
+// retry at the same time. Zero or a negative value means items are processed sequentially.
+//
+// Example:
+//
+//	failed := ebo.RetryEach(items, process, ebo.WithConcurrency(10))
+func WithConcurrency(n int) Option {
+	return func(c *RetryConfig) {
+		c.concurrency = n
+	}
+}
+
+// Adaptive makes a Retrier learn its starting interval from recent outcomes.
+// Every failed attempt multiplies the base interval by increase and every
+// success multiplies it by decrease, so the backoff rises while failures cluster
+// and decays after sustained success. The base interval never drops below
+// Initial nor exceeds Max. Out of range factors (increase <= 1, decrease
+// outside (0, 1)) fall back to 2.0 and 0.5.
+//
+// Adaptive only has an effect on a Retrier, since plain Retry keeps no state between calls.
+//
+// Example:
+//
+//	retrier := ebo.NewRetrier(ebo.Initial(100*time.Millisecond), ebo.Max(10*time.Second), ebo.Adaptive(2.0, 0.8))
+func Adaptive(increase, decrease float64) Option {
+	if increase <= 1 {
+		increase = defaultAdaptiveIncrease
+	}
+	if decrease <= 0 || decrease >= 1 {
+		decrease = defaultAdaptiveDecrease
+	}
+
+	return func(c *RetryConfig) {
+		c.adaptiveIncrease = increase
+		c.adaptiveDecrease = decrease
+	}
+}
+
+// MaxKeys bounds the number of keys a Retrier keeps DoKeyed state for.
+// When a new key would exceed n, the least recently used key is forgotten and
+// starts over with fresh state on its next call. Values <= 0 mean no limit.
+//
+// Example:
+//
+//	retrier := ebo.NewRetrier(ebo.Adaptive(2.0, 0.5), ebo.MaxKeys(10000))
+func MaxKeys(n int) Option {
+	return func(c *RetryConfig) {
+		c.maxKeys = n
+	}
+}
+
+// KeyTTL makes a Retrier forget the DoKeyed state of keys that have not been
+// used for d, so keys that come and go do not accumulate. Values <= 0 keep
+// state until evicted by MaxKeys.
+//
+// Example:
+//
+//	retrier := ebo.NewRetrier(ebo.Adaptive(2.0, 0.5), ebo.KeyTTL(time.Hour))
+func KeyTTL(d time.Duration) Option {
+	return func(c *RetryConfig) {
+		c.keyTTL = d
+	}
+}
+
+// RetryOnJSONField makes HTTPDo, HTTPRetryTransport and RetryMiddleware also
+// retry responses whose JSON body has one of values at the dotted path, for
+// APIs that report soft failures in a 200 payload. Numbers and booleans are
+// compared by their text form. Only application/json responses of up to
+// 256KB are inspected; the body is read to peek at it and put back, so the
+// final response can still be read by the caller.
+// It is combined with the configured ResponseChecker: a response is retried
+// if either says so.
+//
+// Example:
+//
+//	// Retry {"error": {"code": "RATE_LIMITED"}} and {"error": {"code": "BUSY"}}
+//	resp, err := ebo.HTTPDo(req, nil, ebo.API(), ebo.RetryOnJSONField("error.code", "RATE_LIMITED", "BUSY"))
+func RetryOnJSONField(path string, values ...string) Option {
+	return func(c *RetryConfig) {
+		c.bodyChecker = jsonFieldChecker(path, values...)
+	}
+}
+
+// Upstreams makes RetryMiddleware fail over between upstreams: each attempt
+// rewrites the request URL and Host to the next upstream in order, wrapping
+// around at the end of the list, so a retryable response from one upstream
+// is retried against the next. The wrapped handler must send the request to
+// r.URL, as an httputil.ReverseProxy with an empty Director does.
+//
+// Failing over can deliver a request twice, so requests with a
+// non-idempotent method (POST, PATCH, ...) are sent once, to the first upstream.
+//
+// Example:
+//
+//	primary, _ := url.Parse("http://primary.internal:8080")
+//	secondary, _ := url.Parse("http://secondary.internal:8080")
+//
+//	proxy := &httputil.ReverseProxy{Director: func(*http.Request) {}}
+//	handler := ebo.NewRetryMiddleware(proxy, nil, ebo.Tries(4), ebo.Upstreams(primary, secondary))
+func Upstreams(urls ...*url.URL) Option {
+	return func(c *RetryConfig) {
+		c.upstreams = urls
+	}
+}
+
+// ShuffleUpstreams makes RetryMiddleware fail over between the Upstreams in
+// a random order drawn for every request, instead of always starting with the
+// first one, spreading the load across healthy upstreams. Each attempt still
+// follows the backoff schedule. The order comes from the jitter source, so
+// WithDeterministicJitter or JitterSeedFunc make it reproducible.
+//
+// Example:
+//
+//	handler := ebo.NewRetryMiddleware(proxy, nil, ebo.Tries(3), ebo.Upstreams(a, b, c), ebo.ShuffleUpstreams())
+func ShuffleUpstreams() Option {
+	return func(c *RetryConfig) {
+		c.shuffleUpstreams = true
+	}
+}
+
+// PanicAsPermanent makes RetryMiddleware stop at the first handler panic
+// instead of retrying it. Either way the panic is recovered, reported to the
+// MiddlewareOnRetry and MiddlewareOnGiveUp hooks as a 500 and to the
+// RetryLogger as a *PanicError, and a 500 response is written if no attempt
+// succeeds.
+//
+// Example:
+//
+//	// A panic is a bug; retrying will not fix it
+//	mw := ebo.Middleware(ebo.DefaultResponseChecker, ebo.API(), ebo.PanicAsPermanent())
+func PanicAsPermanent() Option {
+	return func(c *RetryConfig) {
+		c.panicAsPermanent = true
+	}
+}
+
+// BypassHeader makes RetryMiddleware pass requests carrying the header name
+// straight to the handler, once and without retries, writing its response
+// as-is. With values, the header must have one of them. Operators can then
+// reproduce an upstream error on a single request without a redeploy.
+//
+// Example:
+//
+//	mw := ebo.Middleware(ebo.DefaultResponseChecker, ebo.API(), ebo.BypassHeader("X-Ebo-Bypass", "1"))
+//
+//	// curl -H 'X-Ebo-Bypass: 1' https://api.example.com/flaky
+func BypassHeader(name string, values ...string) Option {
+	return func(c *RetryConfig) {
+		c.bypassHeader = name
+		c.bypassValues = values
+	}
+}
+
+// MiddlewareOnRetry sets a hook that RetryMiddleware calls before retrying a
+// request, with the number of the attempt that failed and its status code.
+// It runs once the retry is decided, before the backoff sleep, right after
+// the logger's LogRetry and before OnRetry. Useful for per-route retry metrics.
+//
+// Example:
+//
+//	mw := ebo.Middleware(ebo.DefaultResponseChecker, ebo.API(),
+//	    ebo.MiddlewareOnRetry(func(r *http.Request, attempt, status int) {
+//	        retries.WithLabelValues(r.URL.Path).Inc()
+//	    }))
+func MiddlewareOnRetry(hook MiddlewareHook) Option {
+	return func(c *RetryConfig) {
+		c.middlewareOnRetry = hook
+	}
+}
+
+// MiddlewareOnGiveUp sets a hook that RetryMiddleware calls when it stops
+// retrying a request without success, with the total number of attempts and
+// the status code of the response that is written.
+//
+// Example:
+//
+//	mw := ebo.Middleware(ebo.DefaultResponseChecker, ebo.API(),
+//	    ebo.MiddlewareOnGiveUp(func(r *http.Request, attempts, status int) {
+//	        log.Printf("%s %s failed after %d attempts: %d", r.Method, r.URL.Path, attempts, status)
+//	    }))
+func MiddlewareOnGiveUp(hook MiddlewareHook) Option {
+	return func(c *RetryConfig) {
+		c.middlewareOnGiveUp = hook
+	}
+}
+
+// NoDelay makes every attempt run right after the previous one, with no
+// sleep at all: FirstDelay, jitter, Retry-After and the 1ms floor that
+// otherwise keeps retries from busy-looping are all ignored, so bound the loop
+// with Tries. It is meant for fast in-memory retries such as compare-and-swap
+// loops, not for network calls, where it would hammer the other side.
+//
+// Example:
+//
+//	err := ebo.Retry(func() error {
+//	    old := counter.Load()
+//	    if !counter.CompareAndSwap(old, old+1) {
+//	        return errConflict
+//	    }
+//	    return nil
+//	}, ebo.NoDelay(), ebo.Tries(5))
+func NoDelay() Option {
+	return func(c *RetryConfig) {
+		c.noDelay = true
+	}
+}
+
+// ImmediateFirstRetry makes the first retry happen without any delay.
+// Transient blips often clear instantly, so this gives one free fast retry;
+// later attempts keep the normal exponential schedule.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.ImmediateFirstRetry(), ebo.Initial(100*time.Millisecond))
+func ImmediateFirstRetry() Option {
+	return func(c *RetryConfig) {
+		c.immediateFirstRetry = true
+	}
+}
+
+// WarmUpTries makes the first n retries wait interval, typically tighter than
+// Initial, before settling into the normal schedule, which then starts from
+// Initial. It suits cold starts, such as a first connect to a pool, that are
+// expected to be flaky but recover fast. The warm-up retries count toward
+// Tries, and jitter applies to them as usual.
+//
+// Example:
+//
+//	// Retry the cold connect 3 times every 50ms, then back off from 1s
+//	err := ebo.Retry(connect, ebo.WarmUpTries(3, 50*time.Millisecond), ebo.Initial(time.Second), ebo.Tries(10))
+func WarmUpTries(n int, interval time.Duration) Option {
+	return func(c *RetryConfig) {
+		c.warmUpTries = n
+		c.warmUpInterval = interval
+	}
+}
+
+// WithTimeBudget makes the retry loop draw the time it spends from budget,
+// shared with the other retry loops using it, and stop like MaxElapsedTime
+// once the budget is exhausted or too low for the next backoff sleep. If it is
+// exhausted before the first attempt, fn is not called and the error is
+// ErrMaxElapsed. It applies in addition to MaxElapsedTime and the context deadline.
+//
+// Example:
+//
+//	budget := ebo.NewTimeBudget(time.Second) // Overall SLA of the request
+//	errA := ebo.Retry(callBackendA, ebo.WithTimeBudget(budget))
+//	errB := ebo.Retry(callBackendB, ebo.WithTimeBudget(budget))
+func WithTimeBudget(budget *TimeBudget) Option {
+	return func(c *RetryConfig) {
+		c.budget = budget
+	}
+}
+
+// FirstDelay waits d before the first attempt, for work that should start
+// after a known cool-down. The wait is interrupted by the context and
+// WithStopChan, counts toward MaxElapsedTime and is not jittered.
+//
+// Example:
+//
+//	// Give the freshly deployed service 5s to come up, then start probing
+//	err := ebo.RetryCtx(ctx, probe, ebo.FirstDelay(5*time.Second), ebo.Tries(10))
+func FirstDelay(d time.Duration) Option {
+	return func(c *RetryConfig) {
+		c.firstDelay = d
+	}
+}
+
+// WithClassifier sets an ErrorClassifier that decides which errors stop
+// retrying. Errors classified as Permanent are returned immediately, while
+// Retryable and Unknown errors are retried as usual.
+//
+// Example:
+//
+//	classify := func(err error) ebo.Classification {
+//	    switch {
+//	    case errors.Is(err, ErrNotFound), errors.Is(err, ErrAuthFailed):
+//	        return ebo.Permanent
+//	    case errors.Is(err, ErrTimeout):
+//	        return ebo.Retryable
+//	    }
+//	    return ebo.Unknown
+//	}
+//
+//	err := ebo.Retry(fn, ebo.WithClassifier(classify))
+func WithClassifier(classifier ErrorClassifier) Option {
+	return func(c *RetryConfig) {
+		c.classifier = classifier
+	}
+}
+
+// WithPermanentDetector lets Retry recognize a codebase's existing "do not
+// retry" errors without wrapping them: errors for which detect returns true
+// are returned immediately. It composes with the built-in permanent handling
+// and WithClassifier; an error is permanent if any of them says so.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.WithPermanentDetector(func(err error) bool {
+//	    var forbidden *api.ForbiddenError
+//	    return errors.As(err, &forbidden)
+//	}))
+func WithPermanentDetector(detect func(error) bool) Option {
+	return func(c *RetryConfig) {
+		c.permanentDetector = detect
+	}
+}
+
+// StopOnDeadlineExceeded treats context.DeadlineExceeded and context.Canceled
+// returned by fn, usually from its own per-attempt deadline, as permanent.
+// Such errors often mean the overall deadline is blown, so further attempts
+// are pointless. It combines with WithPermanentDetector and WithClassifier:
+// an error is permanent if any of them says so.
+//
+// Example:
+//
+//	err := ebo.Retry(func() error {
+//	    ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+//	    defer cancel()
+//	    return client.Call(ctx)
+//	}, ebo.StopOnDeadlineExceeded())
+func StopOnDeadlineExceeded() Option {
+	return func(c *RetryConfig) {
+		c.stopOnContextErr = true
+	}
+}
+
+// WithAggregateErrorPolicy decides whether errors joining several errors,
+// such as those built with errors.Join by a batch operation that partly
+// failed, are retried. classify classifies every sub-error, where Permanent
+// means not retryable; policy then retries the whole error if all
+// (AllRetryable) or any (AnyRetryable) of them are retryable. Errors that do
+// not join several errors are not affected.
+//
+// Example:
+//
+//	// Retry the batch as long as one of the failed items may still succeed
+//	err := ebo.Retry(syncBatch, ebo.WithAggregateErrorPolicy(ebo.AnyRetryable, classifyItemErr))
+func WithAggregateErrorPolicy(policy AggregatePolicy, classify ErrorClassifier) Option {
+	return func(c *RetryConfig) {
+		c.aggregatePolicy = policy
+		c.aggregateClassifier = classify
+	}
+}
+
+// WithLogger sets a RetryLogger that is told about every attempt, every
+// upcoming retry and the final give-up. It works with Retry, the iterators,
+// DoWithAttempts and RetryMiddleware alike.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.API(), ebo.WithLogger(ebo.SlogLogger(slog.Default())))
+func WithLogger(logger RetryLogger) Option {
+	return func(c *RetryConfig) {
+		c.logger = logger
+	}
+}
+
+// BlockOnEvents makes RetryWithEvents wait for room on its channel instead of
+// dropping events when it is full, so a reader sees every event at the cost of
+// holding up retrying. Waiting ends when the context set with WithContext is done.
+//
+// Example:
+//
+//	err := ebo.RetryWithEvents(fn, events, ebo.BlockOnEvents())
+func BlockOnEvents() Option {
+	return func(c *RetryConfig) {
+		c.blockEvents = true
+	}
+}
+
+// EscalateAfter makes SlogLogger quiet for short blips and loud for sustained
+// failures: the first n failed attempts are logged at Info and their retries
+// at Debug, later ones at Warn, and giving up at Error. It is applied after all
+// options, so it can be given before or after WithLogger. Loggers without
+// levels, such as StdLogger, are unaffected.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.WithLogger(ebo.SlogLogger(slog.Default())), ebo.EscalateAfter(3))
+func EscalateAfter(n int) Option {
+	return func(c *RetryConfig) {
+		c.escalateAfter = n
+	}
+}
+
+// OnRetry sets a hook called before every retry, before its backoff sleep,
+// with the number of the attempt about to run, the delay before it and the
+// signed offset that jitter added to the base delay. The jitter is 0 when a
+// RetryAfter error sets the delay. It helps to check that jitter spreads
+// delays as intended.
+//
+// Example:
+//
+//	err := ebo.Retry(fn, ebo.OnRetry(func(attempt int, delay, jitter time.Duration) {
+//	    log.Printf("attempt %d in %v (jitter %+v)", attempt, delay, jitter)
+//	}))
+func OnRetry(hook func(attempt int, delay, jitter time.Duration)) Option {
+	return func(c *RetryConfig) {
+		c.onRetry = hook
+	}
+}
+
+// OnContextDone registers cleanup that runs exactly once if the context,
+// as given to RetryCtx or WithContext, ends while Retry is running, for
+// example to release resources the attempts hold. It is run with
+// context.AfterFunc, possibly on its own goroutine, and is not called when
+// Retry returns before the context ends.
+//
+// Example:
+//
+//	err := ebo.RetryCtx(ctx, func() error {
+//	    return upload(ctx, session)
+//	}, ebo.API(), ebo.OnContextDone(func() {
+//	    session.Abort()
+//	}))
+func OnContextDone(cleanup func()) Option {
+	return func(c *RetryConfig) {
+		c.onContextDone = cleanup
+	}
+}
+
+// WithSleepHook sets functions that run right before and right after every
+// backoff sleep. after always runs, even when the sleep is cut short by
+// cancellation. Typical use is releasing a worker pool slot while a failing
+// job backs off, so healthy jobs keep their throughput. Either hook may be nil.
+//
+// Example:
+//
+//	sem := make(chan struct{}, 4)
+//	sem <- struct{}{} // acquire a slot for this job
+//	defer func() { <-sem }()
+//
+//	err := ebo.Retry(job, ebo.WithSleepHook(
+//	    func() { <-sem },             // release the slot while sleeping
+//	    func() { sem <- struct{}{} }, // reacquire it before the next attempt
+//	))
+func WithSleepHook(before, after func()) Option {
+	return func(c *RetryConfig) {
+		c.beforeSleep = before
+		c.afterSleep = after
+	}
+}
+
+// WithTracer sets a Tracer that wraps every attempt in a span named
+// "retry.attempt". With DoWithAttemptsContext the span context becomes
+// attempt.Context, so spans started by the retried work nest under it.
+//
+// Example:
+//
+//	err := ebo.DoWithAttemptsContext(ctx, func(attempt *ebo.Attempt) error {
+//	    return callAPI(attempt.Context)
+//	}, ebo.WithTracer(otelTracer{tracer: otel.Tracer("billing")}))
+func WithTracer(tracer Tracer) Option {
+	return func(c *RetryConfig) {
+		c.tracer = tracer
+	}
+}
+
+// OnCleanup sets a function called after every failed attempt, before the
+// backoff sleep, so that resources acquired by a partially successful attempt
+// (connections, locks) can be released. It also runs for the last failed
+// attempt when retrying gives up, whether because the attempts ran out, the
+// error was permanent or the context was cancelled, so every failed attempt
+// is cleaned up exactly once. It is not called after a successful attempt.
+//
+// Example:
+//
+//	var conn net.Conn
+//	err := ebo.Retry(func() error {
+//	    var err error
+//	    if conn, err = net.Dial("tcp", addr); err != nil {
+//	        return err
+//	    }
+//	    return handshake(conn)
+//	}, ebo.OnCleanup(func(attempt int) {
+//	    if conn != nil {
+//	        conn.Close()
+//	        conn = nil
+//	    }
+//	}))
+func OnCleanup(fn func(attempt int)) Option {
+	return func(c *RetryConfig) {
+		c.cleanup = fn
+	}
+}
+
+// WithNetErrorClassifier replaces IsRetryableNetErr as the function deciding
+// which transport errors HTTPRetryTransport and NewHTTPClient retry.
+// Errors for which it returns false are returned without retrying.
+//
+// Example:
+//
+//	client := ebo.NewHTTPClient(ebo.API(), ebo.WithNetErrorClassifier(func(err error) bool {
+//	    return ebo.IsRetryableNetErr(err) || errors.Is(err, errProxyBusy)
+//	}))
+func WithNetErrorClassifier(fn func(error) bool) Option {
+	return func(c *RetryConfig) {
+		c.netErrClassifier = fn
+	}
+}
+
+// AttemptTimeout bounds every attempt of HTTPRetryTransport and NewHTTPClient
+// to d, so a hung server costs one attempt rather than the whole request. Each
+// attempt is sent with a context whose deadline is the earlier of the
+// request's own and d from the start of the attempt, so attempts near the
+// request deadline get a correspondingly shorter timeout. An attempt that
+// times out is retried while the request context is still live.
+//
+// Example:
+//
+//	client := ebo.NewHTTPClient(ebo.API(), ebo.AttemptTimeout(2*time.Second))
+func AttemptTimeout(d time.Duration) Option {
+	return func(c *RetryConfig) {
+		c.attemptTimeout = d
+	}
+}
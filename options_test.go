@@ -123,6 +123,25 @@ func TestPresets(t *testing.T) {
 		}
 	})
 
+	t.Run("Poll", func(t *testing.T) {
+		config := newConfig(Poll(100*time.Millisecond), withRandom(1))
+		if config.MaxRetries != 0 || config.MaxElapsedTime != defaultMaxElapsedTime {
+			t.Errorf("expected unlimited retries bounded by the default MaxTime, got %d and %v", config.MaxRetries, config.MaxElapsedTime)
+		}
+
+		distinct := map[time.Duration]bool{}
+		for n := 2; n <= 50; n++ {
+			d := config.delay(n)
+			if d < 90*time.Millisecond || d > 110*time.Millisecond {
+				t.Fatalf("attempt %d: delay %v outside [90ms, 110ms]", n, d)
+			}
+			distinct[d] = true
+		}
+		if len(distinct) < 2 {
+			t.Error("expected jittered delays, got a single value")
+		}
+	})
+
 	t.Run("Gentle", func(t *testing.T) {
 		config := &RetryConfig{}
 		Gentle()(config)
@@ -172,3 +191,39 @@ func TestCombiningOptions(t *testing.T) {
 		t.Errorf("expected 0 from NoJitter, got %f", config.RandomizeFactor)
 	}
 }
+
+func TestReachMaxBy(t *testing.T) {
+	t.Run("fifth interval reaches max", func(t *testing.T) {
+		config := newConfig(Initial(100*time.Millisecond), Max(10*time.Second), ReachMaxBy(5), NoJitter())
+
+		// The n-th retry interval is the delay before attempt n+1
+		if got := config.delay(2); got != 100*time.Millisecond {
+			t.Errorf("first interval: expected 100ms, got %v", got)
+		}
+		if got := config.delay(5); got >= 10*time.Second {
+			t.Errorf("fourth interval: expected below max, got %v", got)
+		}
+		if got := config.delay(6); got < 9990*time.Millisecond || got > 10*time.Second {
+			t.Errorf("fifth interval: expected ~10s, got %v", got)
+		}
+	})
+
+	t.Run("order independent", func(t *testing.T) {
+		a := newConfig(ReachMaxBy(5), Initial(100*time.Millisecond), Max(10*time.Second))
+		b := newConfig(Initial(100*time.Millisecond), Max(10*time.Second), ReachMaxBy(5))
+		if a.Multiplier != b.Multiplier {
+			t.Errorf("expected equal multipliers, got %v and %v", a.Multiplier, b.Multiplier)
+		}
+	})
+
+	t.Run("invalid values keep multiplier", func(t *testing.T) {
+		for _, config := range []*RetryConfig{
+			newConfig(Multiplier(3), ReachMaxBy(1)),
+			newConfig(Multiplier(3), Initial(time.Second), Max(time.Second), ReachMaxBy(5)),
+		} {
+			if config.Multiplier != 3 {
+				t.Errorf("expected multiplier 3, got %v", config.Multiplier)
+			}
+		}
+	})
+}
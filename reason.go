@@ -0,0 +1,96 @@
+package ebo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors describing why DoWithAttempts gave up. The last error
+// returned by the retried function is wrapped alongside them.
+var (
+	ErrMaxAttempts = errors.New("ebo: maximum attempts reached")
+	ErrMaxElapsed  = errors.New("ebo: maximum elapsed time exceeded")
+
+	// ErrAborted is returned when the channel set with WithStopChan interrupts retrying.
+	ErrAborted = errors.New("ebo: retrying aborted")
+
+	// ErrBackoffLimit is returned when SetMaxConcurrentBackoffs is saturated
+	// and FailOnBackoffLimit makes retrying give up instead of waiting.
+	ErrBackoffLimit = errors.New("ebo: too many concurrent backoffs")
+
+	// ErrNoAttempts is returned when the function was never called, e.g. because
+	// the context was already done. The cause is wrapped alongside it.
+	ErrNoAttempts = errors.New("ebo: no attempts made")
+)
+
+// StopReason describes why a retry loop stopped.
+type StopReason int
+
+// Possible stop reasons
+const (
+	ReasonSuccess          StopReason = iota // The function succeeded or returned ErrStop
+	ReasonMaxAttempts                        // The maximum number of attempts was reached
+	ReasonMaxElapsed                         // The maximum elapsed time was exceeded
+	ReasonPermanent                          // The function returned a permanent error
+	ReasonContextCancelled                   // The context set with WithContext was done
+	ReasonAborted                            // The channel set with WithStopChan fired
+	ReasonBackoffLimit                       // No backoff slot was free with FailOnBackoffLimit
+)
+
+// String returns a short name for the reason, suitable for metrics labels.
+func (r StopReason) String() string {
+	switch r {
+	case ReasonSuccess:
+		return "success"
+	case ReasonMaxAttempts:
+		return "max_attempts"
+	case ReasonMaxElapsed:
+		return "max_elapsed"
+	case ReasonPermanent:
+		return "permanent"
+	case ReasonContextCancelled:
+		return "context_cancelled"
+	case ReasonAborted:
+		return "aborted"
+	case ReasonBackoffLimit:
+		return "backoff_limit"
+	default:
+		return "unknown"
+	}
+}
+
+// RetryWithReason works like Retry but also reports why retrying stopped,
+// giving a machine-readable outcome without matching error messages.
+//
+// Example:
+//
+//	reason, err := ebo.RetryWithReason(func() error {
+//	    return callAPI()
+//	}, ebo.API())
+//	metrics.Inc("retry_outcome", reason.String())
+func RetryWithReason(fn RetryableFunc, opts ...Option) (StopReason, error) {
+	return retryWithReason(newConfig(opts...), fn)
+}
+
+// stopError combines the sentinel for reason with the last error returned by
+// the retried function. Reasons without a sentinel return err unchanged.
+func stopError(reason StopReason, err error) error {
+	var sentinel error
+	switch reason {
+	case ReasonMaxAttempts:
+		sentinel = ErrMaxAttempts
+	case ReasonMaxElapsed:
+		sentinel = ErrMaxElapsed
+	case ReasonAborted:
+		sentinel = ErrAborted
+	case ReasonBackoffLimit:
+		sentinel = ErrBackoffLimit
+	default:
+		return err
+	}
+
+	if err == nil {
+		return sentinel
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
@@ -0,0 +1,88 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryWithReason(t *testing.T) {
+	permanent := errors.New("permanent")
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		fn      RetryableFunc
+		opts    []Option
+		want    StopReason
+		wantErr bool
+	}{
+		{
+			name: "success",
+			fn:   func() error { return nil },
+			want: ReasonSuccess,
+		},
+		{
+			name: "stop",
+			fn:   func() error { return ErrStop },
+			want: ReasonSuccess,
+		},
+		{
+			name:    "max attempts",
+			fn:      func() error { return errors.New("fail") },
+			opts:    []Option{Tries(2), Initial(time.Millisecond)},
+			want:    ReasonMaxAttempts,
+			wantErr: true,
+		},
+		{
+			name:    "max elapsed",
+			fn:      func() error { return errors.New("fail") },
+			opts:    []Option{Timeout(30 * time.Millisecond), Initial(10 * time.Millisecond), NoJitter(), Linear()},
+			want:    ReasonMaxElapsed,
+			wantErr: true,
+		},
+		{
+			name:    "permanent",
+			fn:      func() error { return &permanentError{permanent} },
+			opts:    []Option{Tries(5)},
+			want:    ReasonPermanent,
+			wantErr: true,
+		},
+		{
+			name:    "context cancelled",
+			fn:      func() error { return nil },
+			opts:    []Option{WithContext(cancelled)},
+			want:    ReasonContextCancelled,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := RetryWithReason(tt.fn, tt.opts...)
+			if reason != tt.want {
+				t.Errorf("expected reason %v, got %v", tt.want, reason)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStopReasonString(t *testing.T) {
+	if got := ReasonMaxElapsed.String(); got != "max_elapsed" {
+		t.Errorf("expected 'max_elapsed', got %q", got)
+	}
+	if got := ReasonAborted.String(); got != "aborted" {
+		t.Errorf("expected 'aborted', got %q", got)
+	}
+	if got := ReasonBackoffLimit.String(); got != "backoff_limit" {
+		t.Errorf("expected 'backoff_limit', got %q", got)
+	}
+	if got := StopReason(99).String(); got != "unknown" {
+		t.Errorf("expected 'unknown', got %q", got)
+	}
+}
@@ -0,0 +1,400 @@
+package ebo
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default factors used by Adaptive when given values are out of range
+const (
+	defaultAdaptiveIncrease = 2.0
+	defaultAdaptiveDecrease = 0.5
+)
+
+// Retrier is a reusable retry policy. Unlike Retry, it keeps state between
+// calls, which options such as Adaptive use to tune the backoff over time.
+// A Retrier is safe for concurrent use.
+//
+// Example:
+//
+//	retrier := ebo.NewRetrier(ebo.API(), ebo.Adaptive(2.0, 0.5))
+//
+//	err := retrier.Retry(func() error {
+//	    return callBackend()
+//	})
+type Retrier struct {
+	config   RetryConfig // Options applied once and copied for every call
+	adaptive *adaptiveController
+	stats    retrierCounters
+	keys     keyedStates // Per-key state for DoKeyed
+	shared   sharedCalls // In-flight calls of DoShared
+}
+
+// statsBuckets is the number of buckets in RetrierStats.Attempts.
+const statsBuckets = 16
+
+// RetrierStats is a snapshot of the cumulative counters of a Retrier.
+// Each field is read atomically, but the fields are read one after another,
+// so a snapshot taken while calls complete may be off by the calls in flight.
+type RetrierStats struct {
+	Calls     uint64 // Completed Retry calls
+	Retries   uint64 // Attempts after the first one, across all calls
+	Successes uint64 // Calls that returned nil
+	GiveUps   uint64 // Calls that returned an error
+
+	// Attempts is a histogram of attempts per call: Attempts[i] counts the
+	// calls that made i+1 attempts. The last bucket also counts longer calls.
+	Attempts [statsBuckets]uint64
+}
+
+// retrierCounters holds the lock-free counters behind RetrierStats.
+type retrierCounters struct {
+	calls     atomic.Uint64
+	retries   atomic.Uint64
+	successes atomic.Uint64
+	giveUps   atomic.Uint64
+	attempts  [statsBuckets]atomic.Uint64
+}
+
+// record counts a completed call that made the given number of attempts.
+func (c *retrierCounters) record(attempts int, err error) {
+	if attempts > 0 {
+		c.retries.Add(uint64(attempts - 1))
+		c.attempts[min(attempts, statsBuckets)-1].Add(1)
+	}
+	if err == nil {
+		c.successes.Add(1)
+	} else {
+		c.giveUps.Add(1)
+	}
+	c.calls.Add(1)
+}
+
+// NewRetrier creates a Retrier that applies opts to every call.
+// The options are applied once, so calling Retry does not rebuild the configuration.
+func NewRetrier(opts ...Option) *Retrier {
+	config := defaultConfig()
+	config.apply(globalDefaults()...)
+	config.apply(opts...)
+	r := &Retrier{config: config}
+	r.adaptive = r.newAdaptive()
+
+	return r
+}
+
+// With returns a new Retrier with opts applied over r's options, for a call
+// site that needs a variant of a shared policy. r is left unchanged, and the
+// new Retrier starts with its own state, such as Stats and the Adaptive interval.
+//
+// Example:
+//
+//	db := ebo.NewRetrier(ebo.Database())
+//	migrations := db.With(ebo.Tries(10), ebo.MaxTime(10*time.Minute))
+func (r *Retrier) With(opts ...Option) *Retrier {
+	config := r.config
+	config.apply(opts...)
+	derived := &Retrier{config: config}
+	derived.adaptive = derived.newAdaptive()
+
+	return derived
+}
+
+// newAdaptive returns a controller for the Adaptive option, or nil if it is not set.
+func (r *Retrier) newAdaptive() *adaptiveController {
+	if r.config.adaptiveIncrease <= 0 {
+		return nil
+	}
+	return &adaptiveController{
+		base:     r.config.InitialInterval,
+		min:      r.config.InitialInterval,
+		max:      r.config.MaxInterval,
+		increase: r.config.adaptiveIncrease,
+		decrease: r.config.adaptiveDecrease,
+	}
+}
+
+// Retry executes fn with the Retrier's options, see Retry.
+func (r *Retrier) Retry(fn RetryableFunc) error {
+	return r.retry(r.adaptive, fn, &r.stats)
+}
+
+// DoKeyed is Retry with state kept separately for every key, such as a
+// tenant ID, so one key's failures do not slow down or skew another's:
+// each key has its own Adaptive interval and its own Stats, while the
+// Retrier's Stats count the calls of all keys. Key state is created on first
+// use and kept until evicted by MaxKeys or KeyTTL.
+//
+// Example:
+//
+//	retrier := ebo.NewRetrier(ebo.API(), ebo.Adaptive(2.0, 0.5), ebo.MaxKeys(10000), ebo.KeyTTL(time.Hour))
+//
+//	err := retrier.DoKeyed(job.TenantID, func() error {
+//	    return process(job)
+//	})
+func (r *Retrier) DoKeyed(key string, fn RetryableFunc) error {
+	state := r.keys.get(key, r)
+	return r.retry(state.adaptive, fn, &state.stats, &r.stats)
+}
+
+// DoShared is RetryValue run by r for callers that share key: while a call
+// for key is in flight, further calls with key wait for it and receive its
+// value and error instead of retrying fn themselves, like
+// golang.org/x/sync/singleflight with the retries built in. Once the call
+// completes, the next call with key starts a new one. This keeps a storm of
+// identical operations, such as refilling a cache entry on a miss, from
+// multiplying the load on a struggling backend. It is a function rather than
+// a method because methods cannot have type parameters; all calls with a key
+// must use the same T.
+//
+// Example:
+//
+//	retrier := ebo.NewRetrier(ebo.API())
+//
+//	user, err := ebo.DoShared(retrier, "user:"+id, func() (*User, error) {
+//	    return db.LoadUser(id)
+//	})
+func DoShared[T any](r *Retrier, key string, fn func() (T, error)) (T, error) {
+	call, leader := r.shared.join(key)
+	if !leader {
+		<-call.done
+		value, _ := call.value.(T)
+		return value, call.err
+	}
+	defer r.shared.finish(key, call)
+
+	var value T
+	err := r.Retry(func() error {
+		v, err := fn()
+		if err != nil && !errors.Is(err, ErrStop) {
+			return err
+		}
+		value = v
+		return err
+	})
+	call.value, call.err = value, err
+
+	return value, err
+}
+
+// retry runs fn with adaptive tuning the base interval, if not nil,
+// and records the call in counters.
+func (r *Retrier) retry(adaptive *adaptiveController, fn RetryableFunc, counters ...*retrierCounters) error {
+	config := r.config
+	config.start()
+
+	if adaptive != nil {
+		config.InitialInterval = adaptive.interval()
+		next := fn
+		fn = func() error {
+			err := next()
+			adaptive.observe(err)
+			return err
+		}
+	}
+
+	attempts := 0
+	err := retry(&config, func() error {
+		attempts++
+		return fn()
+	})
+	for _, c := range counters {
+		c.record(attempts, err)
+	}
+
+	return err
+}
+
+// Stats returns a snapshot of the Retrier's cumulative counters, updated as
+// calls to Retry complete. It is safe to call concurrently with Retry.
+//
+// Example:
+//
+//	stats := retrier.Stats()
+//	log.Printf("calls=%d retries=%d give-ups=%d", stats.Calls, stats.Retries, stats.GiveUps)
+func (r *Retrier) Stats() RetrierStats {
+	return r.stats.snapshot()
+}
+
+// KeyStats returns a snapshot of the counters of the calls made by DoKeyed
+// for key. It reports false if key has not been used or was evicted.
+func (r *Retrier) KeyStats(key string) (RetrierStats, bool) {
+	state, ok := r.keys.lookup(key)
+	if !ok {
+		return RetrierStats{}, false
+	}
+	return state.stats.snapshot(), true
+}
+
+// snapshot reads the counters into a RetrierStats.
+func (c *retrierCounters) snapshot() RetrierStats {
+	stats := RetrierStats{
+		Calls:     c.calls.Load(),
+		Retries:   c.retries.Load(),
+		Successes: c.successes.Load(),
+		GiveUps:   c.giveUps.Load(),
+	}
+	for i := range stats.Attempts {
+		stats.Attempts[i] = c.attempts[i].Load()
+	}
+	return stats
+}
+
+// BaseInterval returns the initial interval the next call will start with.
+// It only differs from the configured Initial value when Adaptive is used.
+func (r *Retrier) BaseInterval() time.Duration {
+	if r.adaptive == nil {
+		return r.config.InitialInterval
+	}
+	return r.adaptive.interval()
+}
+
+// adaptiveController adjusts the base interval from observed outcomes:
+// it grows multiplicatively on failures and shrinks multiplicatively on
+// successes, staying within [min, max].
+type adaptiveController struct {
+	mu       sync.Mutex
+	base     time.Duration
+	min      time.Duration
+	max      time.Duration
+	increase float64
+	decrease float64
+}
+
+// interval returns the current base interval.
+func (a *adaptiveController) interval() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.base
+}
+
+// observe records the outcome of an attempt.
+func (a *adaptiveController) observe(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	factor := a.decrease
+	if err != nil {
+		factor = a.increase
+	}
+
+	a.base = min(max(time.Duration(float64(a.base)*factor), a.min), a.max)
+}
+
+// keyState is the state DoKeyed keeps for one key.
+type keyState struct {
+	key      string
+	adaptive *adaptiveController
+	stats    retrierCounters
+	lastUsed time.Time
+}
+
+// keyedStates maps keys to their state, evicting the least recently used
+// keys beyond MaxKeys and keys unused for longer than KeyTTL.
+type keyedStates struct {
+	mu     sync.Mutex
+	states map[string]*list.Element // Values are *keyState
+	order  list.List                // Most recently used first
+}
+
+// get returns the state for key, creating it for r if needed.
+func (k *keyedStates) get(key string, r *Retrier) *keyState {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	k.expire(now, r.config.keyTTL)
+
+	if elem, ok := k.states[key]; ok {
+		state := elem.Value.(*keyState)
+		state.lastUsed = now
+		k.order.MoveToFront(elem)
+		return state
+	}
+
+	if k.states == nil {
+		k.states = make(map[string]*list.Element)
+	}
+	state := &keyState{key: key, adaptive: r.newAdaptive(), lastUsed: now}
+	k.states[key] = k.order.PushFront(state)
+
+	if maxKeys := r.config.maxKeys; maxKeys > 0 {
+		for k.order.Len() > maxKeys {
+			k.remove(k.order.Back())
+		}
+	}
+	return state
+}
+
+// lookup returns the state for key without creating it or marking it used.
+func (k *keyedStates) lookup(key string) (*keyState, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	elem, ok := k.states[key]
+	if !ok {
+		return nil, false
+	}
+	return elem.Value.(*keyState), true
+}
+
+// expire removes the keys unused for longer than ttl (0 keeps them).
+func (k *keyedStates) expire(now time.Time, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	for elem := k.order.Back(); elem != nil && now.Sub(elem.Value.(*keyState).lastUsed) > ttl; elem = k.order.Back() {
+		k.remove(elem)
+	}
+}
+
+// remove deletes the state held by elem.
+func (k *keyedStates) remove(elem *list.Element) {
+	delete(k.states, elem.Value.(*keyState).key)
+	k.order.Remove(elem)
+}
+
+// errSharedCallPanicked is returned to the callers waiting on a DoShared call
+// whose fn panicked.
+var errSharedCallPanicked = errors.New("ebo: shared call panicked")
+
+// sharedCall is a DoShared call in flight. value and err are set before done
+// is closed.
+type sharedCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// sharedCalls tracks the DoShared calls in flight by key.
+type sharedCalls struct {
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+}
+
+// join returns the call in flight for key, or starts one and reports that the
+// caller leads it.
+func (s *sharedCalls) join(key string) (*sharedCall, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if call, ok := s.calls[key]; ok {
+		return call, false
+	}
+	if s.calls == nil {
+		s.calls = make(map[string]*sharedCall)
+	}
+	call := &sharedCall{done: make(chan struct{}), err: errSharedCallPanicked}
+	s.calls[key] = call
+	return call, true
+}
+
+// finish releases the callers waiting on call and lets the next call for key start.
+func (s *sharedCalls) finish(key string, call *sharedCall) {
+	s.mu.Lock()
+	delete(s.calls, key)
+	s.mu.Unlock()
+	close(call.done)
+}
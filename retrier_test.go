@@ -0,0 +1,335 @@
+package ebo
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetrier(t *testing.T) {
+	t.Run("applies options", func(t *testing.T) {
+		retrier := NewRetrier(Initial(10*time.Millisecond), Tries(3))
+
+		attempts := 0
+		err := retrier.Retry(func() error {
+			attempts++
+			return errors.New("always fail")
+		})
+
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+		if attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts)
+		}
+		if retrier.BaseInterval() != 10*time.Millisecond {
+			t.Errorf("expected base interval 10ms, got %v", retrier.BaseInterval())
+		}
+	})
+}
+
+func TestRetrierWith(t *testing.T) {
+	base := NewRetrier(Initial(time.Millisecond), Max(4*time.Millisecond), Tries(2))
+	derived := base.With(Tries(4), Initial(2*time.Millisecond))
+
+	count := func(r *Retrier) int {
+		attempts := 0
+		_ = r.Retry(func() error {
+			attempts++
+			return errors.New("always fail")
+		})
+		return attempts
+	}
+
+	if got := count(derived); got != 4 {
+		t.Errorf("expected 4 attempts from the derived retrier, got %d", got)
+	}
+	if got := derived.BaseInterval(); got != 2*time.Millisecond {
+		t.Errorf("expected derived base interval 2ms, got %v", got)
+	}
+	if derived.config.MaxInterval != 4*time.Millisecond {
+		t.Errorf("expected the derived retrier to keep Max 4ms, got %v", derived.config.MaxInterval)
+	}
+
+	if got := count(base); got != 2 {
+		t.Errorf("expected the base retrier to keep 2 attempts, got %d", got)
+	}
+	if got := base.BaseInterval(); got != time.Millisecond {
+		t.Errorf("expected base interval 1ms to be untouched, got %v", got)
+	}
+	if calls := base.Stats().Calls; calls != 1 {
+		t.Errorf("expected the base retrier to count only its own call, got %d", calls)
+	}
+}
+
+func TestRetrierStats(t *testing.T) {
+	retrier := NewRetrier(Initial(time.Microsecond), Max(time.Microsecond), Tries(3))
+
+	const workers, perWorker = 8, 50
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWorker {
+				// Cycle through success on attempt 1, success on attempt 2, and give-up after 3
+				failures := (w + i) % 3
+				if failures == 2 {
+					failures = 3
+				}
+				attempts := 0
+				_ = retrier.Retry(func() error {
+					attempts++
+					if attempts <= failures {
+						return errors.New("fail")
+					}
+					return nil
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := retrier.Stats()
+	total := uint64(workers * perWorker)
+	if stats.Calls != total {
+		t.Errorf("expected %d calls, got %d", total, stats.Calls)
+	}
+	if stats.Successes+stats.GiveUps != stats.Calls {
+		t.Errorf("expected successes (%d) + give-ups (%d) to equal calls (%d)", stats.Successes, stats.GiveUps, stats.Calls)
+	}
+
+	var histogramCalls, histogramRetries uint64
+	for i, n := range stats.Attempts {
+		histogramCalls += n
+		histogramRetries += n * uint64(i)
+	}
+	if histogramCalls != stats.Calls {
+		t.Errorf("expected histogram to sum to %d calls, got %d", stats.Calls, histogramCalls)
+	}
+	if histogramRetries != stats.Retries {
+		t.Errorf("expected %d retries from histogram, got %d", histogramRetries, stats.Retries)
+	}
+	// Only the calls that give up make 3 attempts
+	if stats.GiveUps == 0 || stats.Attempts[2] != stats.GiveUps {
+		t.Errorf("expected %d calls with 3 attempts, got %v", stats.GiveUps, stats.Attempts)
+	}
+	if stats.Attempts[0] == 0 || stats.Attempts[1] == 0 {
+		t.Errorf("expected calls with 1 and 2 attempts, got %v", stats.Attempts)
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	t.Run("base interval rises then decays", func(t *testing.T) {
+		retrier := NewRetrier(
+			Initial(time.Millisecond),
+			Max(8*time.Millisecond),
+			Tries(4),
+			NoJitter(),
+			Adaptive(2.0, 0.5),
+		)
+
+		// Four failed attempts: 1ms -> 2ms -> 4ms -> 8ms -> capped at 8ms
+		_ = retrier.Retry(func() error {
+			return errors.New("backend down")
+		})
+		if got := retrier.BaseInterval(); got != 8*time.Millisecond {
+			t.Errorf("expected base interval 8ms after failures, got %v", got)
+		}
+
+		// Successful calls halve it again
+		wants := []time.Duration{4 * time.Millisecond, 2 * time.Millisecond, time.Millisecond, time.Millisecond}
+		for i, want := range wants {
+			if err := retrier.Retry(func() error { return nil }); err != nil {
+				t.Fatalf("expected success, got error: %v", err)
+			}
+			if got := retrier.BaseInterval(); got != want {
+				t.Errorf("success %d: expected base interval %v, got %v", i+1, want, got)
+			}
+		}
+	})
+
+	t.Run("invalid factors fall back to defaults", func(t *testing.T) {
+		config := newConfig(Adaptive(0.5, 2))
+		if config.adaptiveIncrease != defaultAdaptiveIncrease {
+			t.Errorf("expected increase %v, got %v", defaultAdaptiveIncrease, config.adaptiveIncrease)
+		}
+		if config.adaptiveDecrease != defaultAdaptiveDecrease {
+			t.Errorf("expected decrease %v, got %v", defaultAdaptiveDecrease, config.adaptiveDecrease)
+		}
+	})
+}
+
+func TestDoKeyed(t *testing.T) {
+	newKeyed := func(opts ...Option) *Retrier {
+		return NewRetrier(append([]Option{
+			Initial(time.Millisecond),
+			Max(8 * time.Millisecond),
+			Tries(3),
+			NoJitter(),
+			Adaptive(2.0, 0.5),
+		}, opts...)...)
+	}
+	fail := func() error { return errors.New("tenant backend down") }
+	succeed := func() error { return nil }
+
+	t.Run("keys keep separate state", func(t *testing.T) {
+		retrier := newKeyed()
+
+		_ = retrier.DoKeyed("noisy", fail)
+		_ = retrier.DoKeyed("noisy", fail)
+		if err := retrier.DoKeyed("quiet", succeed); err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+
+		noisy, _ := retrier.keys.lookup("noisy")
+		quiet, _ := retrier.keys.lookup("quiet")
+		if got := noisy.adaptive.interval(); got != 8*time.Millisecond {
+			t.Errorf("expected noisy base interval 8ms, got %v", got)
+		}
+		if got := quiet.adaptive.interval(); got != time.Millisecond {
+			t.Errorf("expected quiet base interval 1ms, got %v", got)
+		}
+		if got := retrier.BaseInterval(); got != time.Millisecond {
+			t.Errorf("expected the unkeyed base interval to stay 1ms, got %v", got)
+		}
+
+		noisyStats, ok := retrier.KeyStats("noisy")
+		if !ok || noisyStats.Calls != 2 || noisyStats.GiveUps != 2 || noisyStats.Retries != 4 {
+			t.Errorf("unexpected noisy stats: %+v", noisyStats)
+		}
+		quietStats, ok := retrier.KeyStats("quiet")
+		if !ok || quietStats.Calls != 1 || quietStats.Successes != 1 || quietStats.Retries != 0 {
+			t.Errorf("unexpected quiet stats: %+v", quietStats)
+		}
+		if stats := retrier.Stats(); stats.Calls != 3 {
+			t.Errorf("expected 3 calls across keys, got %d", stats.Calls)
+		}
+		if _, ok := retrier.KeyStats("unknown"); ok {
+			t.Error("expected no stats for an unused key")
+		}
+	})
+
+	t.Run("max keys evicts least recently used", func(t *testing.T) {
+		retrier := newKeyed(MaxKeys(2))
+
+		_ = retrier.DoKeyed("a", succeed)
+		_ = retrier.DoKeyed("b", succeed)
+		_ = retrier.DoKeyed("a", succeed)
+		_ = retrier.DoKeyed("c", succeed)
+
+		for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+			if _, ok := retrier.KeyStats(key); ok != want {
+				t.Errorf("key %q: expected kept=%v", key, want)
+			}
+		}
+	})
+
+	t.Run("ttl evicts idle keys", func(t *testing.T) {
+		retrier := newKeyed(KeyTTL(20 * time.Millisecond))
+
+		_ = retrier.DoKeyed("idle", fail)
+		time.Sleep(30 * time.Millisecond)
+		_ = retrier.DoKeyed("active", succeed)
+
+		if _, ok := retrier.KeyStats("idle"); ok {
+			t.Error("expected idle key to be evicted")
+		}
+		if _, ok := retrier.KeyStats("active"); !ok {
+			t.Error("expected active key to be kept")
+		}
+	})
+
+	t.Run("concurrent keys", func(t *testing.T) {
+		retrier := newKeyed(MaxKeys(4))
+
+		var wg sync.WaitGroup
+		for i := range 32 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = retrier.DoKeyed(string(rune('a'+i%8)), succeed)
+			}()
+		}
+		wg.Wait()
+
+		if stats := retrier.Stats(); stats.Calls != 32 {
+			t.Errorf("expected 32 calls, got %d", stats.Calls)
+		}
+		if n := len(retrier.keys.states); n > 4 {
+			t.Errorf("expected at most 4 keys, got %d", n)
+		}
+	})
+}
+
+func TestDoShared(t *testing.T) {
+	t.Run("concurrent callers share one call", func(t *testing.T) {
+		const callers = 50
+		retrier := NewRetrier(Initial(time.Millisecond), NoJitter(), Tries(5))
+
+		var calls, arrived atomic.Int32
+		fn := func() (string, error) {
+			// Fail twice, then hold the call until every caller has arrived
+			if calls.Add(1) <= 2 {
+				return "", errors.New("cache backend busy")
+			}
+			for arrived.Load() < callers {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(20 * time.Millisecond)
+			return "value", nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]string, callers)
+		errs := make([]error, callers)
+		for i := range callers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				arrived.Add(1)
+				results[i], errs[i] = DoShared(retrier, "user:1", fn)
+			}()
+		}
+		wg.Wait()
+
+		if n := calls.Load(); n != 3 {
+			t.Errorf("expected fn to run 3 times in one retried call, got %d", n)
+		}
+		for i := range callers {
+			if results[i] != "value" || errs[i] != nil {
+				t.Fatalf("caller %d: expected value, got %q and %v", i, results[i], errs[i])
+			}
+		}
+		if stats := retrier.Stats(); stats.Calls != 1 {
+			t.Errorf("expected 1 retried call, got %d", stats.Calls)
+		}
+	})
+
+	t.Run("shares the error", func(t *testing.T) {
+		retrier := NewRetrier(Initial(time.Millisecond), Tries(2))
+		failure := errors.New("failure")
+
+		_, err := DoShared(retrier, "user:1", func() (int, error) { return 0, failure })
+		if !errors.Is(err, failure) {
+			t.Errorf("expected failure, got %v", err)
+		}
+	})
+
+	t.Run("next call starts over", func(t *testing.T) {
+		retrier := NewRetrier()
+		calls := 0
+		for range 2 {
+			_, _ = DoShared(retrier, "user:1", func() (int, error) {
+				calls++
+				return calls, nil
+			})
+		}
+
+		if calls != 2 {
+			t.Errorf("expected a new call once the first completed, got %d calls", calls)
+		}
+	})
+}
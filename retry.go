@@ -1,9 +1,13 @@
 package ebo
 
 import (
+	"context"
 	"errors"
+	"log"
 	"math"
-	"math/rand"
+	"net/http"
+	"net/url"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,37 +19,447 @@ const (
 	defaultMultiplier      = 2.0
 	defaultMaxElapsedTime  = 5 * time.Minute
 	defaultRandomizeFactor = 0.5
+
+	// Fixed schedule of RetryWithBackoff
+	defaultBackoffInitial = 100 * time.Millisecond
+	defaultBackoffMax     = 10 * time.Second
+
+	// minEffectiveInterval replaces a non-positive InitialInterval so that
+	// retries are never issued in a tight loop.
+	minEffectiveInterval = time.Millisecond
+
+	// Interval at which WithPause checks a paused loop when no poll is given
+	defaultPausePoll = 100 * time.Millisecond
 )
 
+// DefaultMaxAttempts is a safety ceiling applied when neither MaxRetries nor
+// MaxElapsedTime is set (for example Forever() without MaxTime). Once reached,
+// a warning is logged and retrying stops instead of looping forever.
+// Set it to 0 to disable the guard.
+//
+// Like SetDefaults, this is global state, read without synchronization by
+// every retry loop: set it only during initialization, before retrying
+// starts. Changing it while retries run is a data race.
+var DefaultMaxAttempts = 1000
+
+// defaultOptions holds the options set with SetDefaults.
+var defaultOptions atomic.Pointer[[]Option]
+
+// SetDefaults sets baseline options applied before the options given at each
+// call site, so call-site options win. Calling it again replaces the defaults;
+// calling it without options clears them. It affects every configuration built
+// afterwards (Retry, the iterators, the HTTP helpers and new Retriers).
+//
+// This is global state: set it once at startup, before retrying starts. It is
+// safe to read concurrently afterwards. Libraries should not call it, since
+// they would change the behavior of the application embedding them.
+//
+// Example:
+//
+//	func main() {
+//	    ebo.SetDefaults(ebo.Jitter(0.3), ebo.MaxTime(30*time.Second))
+//	    // ...
+//	}
+func SetDefaults(opts ...Option) {
+	if len(opts) == 0 {
+		defaultOptions.Store(nil)
+		return
+	}
+	opts = append([]Option(nil), opts...)
+	defaultOptions.Store(&opts)
+}
+
+// globalDefaults returns the options set with SetDefaults.
+func globalDefaults() []Option {
+	if opts := defaultOptions.Load(); opts != nil {
+		return *opts
+	}
+	return nil
+}
+
 // RetryConfig holds the configuration for retry with exponential backoff
 type RetryConfig struct {
 	InitialInterval time.Duration // Initial retry interval
+	MinInterval     time.Duration // Minimum retry interval
 	MaxInterval     time.Duration // Maximum retry interval
 	MaxRetries      int           // Maximum number of retry attempts (0 for no limit)
 	Multiplier      float64       // Backoff multiplier (typically 2.0)
-	MaxElapsedTime  time.Duration // Maximum total time for all retries (0 for no limit)
+	MaxElapsedTime  time.Duration // Maximum total time for all retries (0 for no limit); the first attempt always runs
 	RandomizeFactor float64       // Randomization factor for jitter (0 to 1)
+
+	checker       ResponseChecker  // Decides which HTTP responses are retried by the HTTP helpers
+	bodyChecker   ResponseChecker  // Additionally retries responses based on their body (nil disables)
+	delayChecker  CheckerWithDelay // Replaces the ResponseChecker and may set the next wait (nil disables)
+	statusBackoff map[int]Option   // Backoff options applied to the delay after a response with the status
+	ctx           context.Context  // Cancels the retry loop when done (nil means never)
+	concurrency   int              // Maximum in-flight operations for batch APIs (0 means sequential)
+
+	adaptiveIncrease float64 // Base interval growth factor on failure for an adaptive Retrier (0 disables)
+	adaptiveDecrease float64 // Base interval shrink factor on success for an adaptive Retrier
+
+	maxKeys int           // Keys a Retrier keeps DoKeyed state for, evicting the least recently used (0 for no limit)
+	keyTTL  time.Duration // Evicts DoKeyed state unused for this long (0 keeps it)
+
+	middlewareOnRetry  MiddlewareHook // Called by RetryMiddleware before each retry
+	middlewareOnGiveUp MiddlewareHook // Called by RetryMiddleware when retries are exhausted
+	upstreams          []*url.URL     // Upstreams RetryMiddleware fails over between, in order
+	shuffleUpstreams   bool           // Fail over between upstreams in a random order per request
+	panicAsPermanent   bool           // Stop RetryMiddleware retrying after a handler panic
+	bypassHeader       string         // Request header that makes RetryMiddleware pass requests through ("" disables)
+	bypassValues       []string       // Values of bypassHeader that bypass retries (empty means any)
+
+	immediateFirstRetry bool             // Skip the delay before the second attempt
+	noDelay             bool             // Never wait between attempts, see NoDelay
+	classifier          ErrorClassifier  // Decides which errors are permanent (nil retries all)
+	permanentDetector   func(error) bool // Recognizes a codebase's own permanent errors (nil disables)
+	stopOnContextErr    bool             // Treat context errors returned by fn as permanent
+	aggregatePolicy     AggregatePolicy  // Decides whether aggregate errors are retried
+	aggregateClassifier ErrorClassifier  // Classifies the sub-errors of aggregate errors (nil disables the policy)
+
+	random        func() float64                // Source of jitter in [0, 1) (nil uses math/rand)
+	seed          *int64                        // Reseeds random at the start of every retry loop (nil disables)
+	requestSeed   func(req *http.Request) int64 // Seeds random per request in the HTTP helpers (nil disables)
+	logger        RetryLogger                   // Receives attempt, retry and give-up events (nil disables)
+	escalateAfter int                           // Failures logged quietly before escalating to Warn (0 disables)
+	blockEvents   bool                          // RetryWithEvents waits for room on its channel instead of dropping events
+
+	beforeSleep func() // Called before each backoff sleep
+	afterSleep  func() // Called after each backoff sleep
+	clock       Clock  // Provides the backoff sleep timers (nil uses real timers)
+
+	failOnBackoffLimit bool // Give up instead of waiting when SetMaxConcurrentBackoffs is saturated
+
+	isPaused   func() bool   // Blocks retrying between attempts while it reports true (nil disables)
+	pollPaused time.Duration // How often isPaused is checked while paused
+
+	onRetry       func(attempt int, delay, jitter time.Duration) // Called before each retry with its delay and jitter
+	onContextDone func()                                         // Called once if the context ends during Retry (nil disables)
+
+	deadline  time.Time     // Stop retrying when the next attempt would not fit before this time (zero means none)
+	capJitter float64       // Randomization factor applied once to MaxInterval (0 to 1)
+	maxJitter time.Duration // Largest amount jitter may add to or take from a delay (0 for no limit)
+	tracer    Tracer        // Starts a span around each attempt (nil disables)
+
+	cleanup   func(attempt int) // Called after every failed attempt, before the next sleep or giving up
+	takeRetry func() bool       // Reserves each retry once it is decided, giving up when it reports false (nil disables)
+
+	netErrClassifier func(error) bool // Decides which transport errors HTTPRetryTransport retries (nil uses IsRetryableNetErr)
+	attemptTimeout   time.Duration    // Bounds each HTTPRetryTransport attempt (0 for no limit)
+
+	maxCumulativeDelay time.Duration // Maximum total backoff sleep across all retries (0 for no limit)
+	reachMaxBy         int           // Retry interval that should reach MaxInterval, sets Multiplier (0 disables)
+	multipliers        []float64     // Growth factor per retry, replacing Multiplier (nil disables)
+
+	validResult any // func(T) bool deciding which RetryValue results are accepted (nil accepts all)
+
+	stop <-chan struct{} // Aborts retrying when closed or sent to (nil means never)
+
+	firstDelay     time.Duration // Wait before the first attempt (0 runs it immediately)
+	warmUpTries    int           // Retries that wait warmUpInterval before the normal schedule starts (0 disables)
+	warmUpInterval time.Duration // Wait before each warm-up retry
+	budget         *TimeBudget   // Time shared with other retry loops (nil disables)
+}
+
+// newConfig returns a RetryConfig populated with the defaults, the options
+// set with SetDefaults and the given options applied.
+func newConfig(opts ...Option) *RetryConfig {
+	config := defaultConfig()
+	config.apply(globalDefaults()...)
+	config.apply(opts...)
+	config.start()
+	return &config
+}
+
+// defaultConfig returns a RetryConfig populated with the defaults.
+func defaultConfig() RetryConfig {
+	return RetryConfig{
+		InitialInterval: defaultInitialInterval,
+		MaxInterval:     defaultMaxInterval,
+		MaxRetries:      defaultMaxRetries,
+		Multiplier:      defaultMultiplier,
+		MaxElapsedTime:  defaultMaxElapsedTime,
+		RandomizeFactor: defaultRandomizeFactor,
+	}
+}
+
+// apply applies opts in order, then derives settings that depend on several of them.
+func (c *RetryConfig) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.reachMaxBy > 1 && c.InitialInterval > 0 && c.MaxInterval > c.InitialInterval {
+		ratio := float64(c.MaxInterval) / float64(c.InitialInterval)
+		c.Multiplier = math.Pow(ratio, 1/float64(c.reachMaxBy-1))
+	}
+	if logger, ok := c.logger.(escalatingLogger); ok && c.escalateAfter > 0 {
+		c.logger = logger.escalate(c.escalateAfter)
+	}
+}
+
+// start prepares a copy of a base configuration for a single retry loop.
+func (c *RetryConfig) start() {
+	// Replay the same jitter in every retry loop
+	if c.seed != nil {
+		c.random = seededRandom(*c.seed)
+	}
+	// Give this retry loop its own ceiling so clients at Max do not synchronize
+	if c.capJitter > 0 {
+		c.MaxInterval = c.jitterMax()
+	}
+}
+
+// reachedSafetyLimit reports whether an unbounded configuration has made
+// DefaultMaxAttempts attempts, logging a warning when it has.
+func (c *RetryConfig) reachedSafetyLimit(attempts int) bool {
+	if c.MaxRetries > 0 || c.MaxElapsedTime > 0 || c.maxCumulativeDelay > 0 || DefaultMaxAttempts <= 0 {
+		return false
+	}
+	if attempts < DefaultMaxAttempts {
+		return false
+	}
+	log.Printf("ebo: no MaxRetries or MaxElapsedTime set, stopping after %d attempts (DefaultMaxAttempts)", attempts)
+	return true
+}
+
+// immediate reports whether the attempt with the given zero-based index
+// runs without waiting first.
+func (c *RetryConfig) immediate(index int) bool {
+	return index == 0 || (index == 1 && c.immediateFirstRetry)
+}
+
+// context returns the configured context or context.Background.
+func (c *RetryConfig) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
 }
 
-// getNextInterval calculates the next retry interval with optional jitter
-func getNextInterval(currentInterval time.Duration, randomizeFactor float64) time.Duration {
-	if randomizeFactor == 0 {
-		return currentInterval
+// sleep waits for d or until ctx is done, returning the context error in the latter case.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
+
+// backoffSlots holds a token for every backoff sleep in progress, up to the
+// limit set with SetMaxConcurrentBackoffs (nil means unlimited).
+var backoffSlots atomic.Pointer[chan struct{}]
 
-	delta := randomizeFactor * float64(currentInterval)
-	minInterval := float64(currentInterval) - delta
-	maxInterval := float64(currentInterval) + delta
-	return time.Duration(minInterval + (rand.Float64() * (maxInterval - minInterval)))
+// SetMaxConcurrentBackoffs limits how many retry loops, across the whole
+// process, can be sleeping between attempts at once, bounding the goroutines
+// and timers parked in backoff during a wide outage. A loop that finds every
+// slot taken waits for one, and then sleeps its full delay, unless
+// FailOnBackoffLimit makes it give up. n <= 0 removes the limit, which is the
+// default.
+//
+// This is global state: set it once at startup, before retrying starts.
+// Sleeps already waiting keep the limit they started with.
+//
+// Example:
+//
+//	func main() {
+//	    ebo.SetMaxConcurrentBackoffs(1000)
+//	    // ...
+//	}
+func SetMaxConcurrentBackoffs(n int) {
+	if n <= 0 {
+		backoffSlots.Store(nil)
+		return
+	}
+	slots := make(chan struct{}, n)
+	backoffSlots.Store(&slots)
+}
+
+// acquireBackoff takes a slot for a backoff sleep from slots, waiting for one
+// unless FailOnBackoffLimit is set.
+func (c *RetryConfig) acquireBackoff(ctx context.Context, slots chan struct{}) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if c.failOnBackoffLimit {
+		return ErrBackoffLimit
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.stop:
+		return ErrAborted
+	}
+}
+
+// wait sleeps for d like sleep, on the configured Clock if any, running the
+// configured sleep hooks around it. It returns ErrAborted if the stop channel
+// fires first, and ErrBackoffLimit if FailOnBackoffLimit finds no backoff slot.
+func (c *RetryConfig) wait(ctx context.Context, d time.Duration) error {
+	if c.beforeSleep != nil {
+		c.beforeSleep()
+	}
+	if c.afterSleep != nil {
+		defer c.afterSleep()
+	}
+	if slots := backoffSlots.Load(); slots != nil {
+		if err := c.acquireBackoff(ctx, *slots); err != nil {
+			return err
+		}
+		defer func() { <-*slots }()
+	}
+	if c.stop == nil && c.clock == nil {
+		return sleep(ctx, d)
+	}
+
+	var fired <-chan time.Time
+	if c.clock != nil {
+		fired = c.clock.After(d)
+	} else {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		fired = timer.C
+	}
+
+	select {
+	case <-fired:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.stop:
+		return ErrAborted
+	}
+}
+
+// pause blocks while the WithPause check reports paused, polling it, and
+// returns how long it blocked. It returns early, like wait, when ctx is done
+// or the stop channel fires.
+func (c *RetryConfig) pause(ctx context.Context) (time.Duration, error) {
+	if !c.isPaused() {
+		return 0, nil
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(c.pollPaused)
+	defer ticker.Stop()
+
+	for c.isPaused() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return time.Since(start), ctx.Err()
+		case <-c.stop:
+			return time.Since(start), ErrAborted
+		}
+	}
+	return time.Since(start), nil
+}
+
+// waitReason returns the reason retrying stops when wait fails with err.
+func waitReason(err error) StopReason {
+	switch {
+	case errors.Is(err, ErrAborted):
+		return ReasonAborted
+	case errors.Is(err, ErrBackoffLimit):
+		return ReasonBackoffLimit
+	default:
+		return ReasonContextCancelled
+	}
+}
+
+// stopped reports whether the stop channel has fired, without blocking.
+func (c *RetryConfig) stopped() bool {
+	if c.stop == nil {
+		return false
+	}
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// cleanupAttempt runs the cleanup callback, if any, for a failed attempt.
+func (c *RetryConfig) cleanupAttempt(attempt int) {
+	if c.cleanup != nil {
+		c.cleanup(attempt)
+	}
+}
+
+// responseChecker returns the configured ResponseChecker or the default one,
+// combined with the body checker if any.
+func (c *RetryConfig) responseChecker() ResponseChecker {
+	checker := c.checker
+	if checker == nil {
+		checker = DefaultResponseChecker
+	}
+	if c.bodyChecker != nil {
+		return AnyChecker(checker, c.bodyChecker)
+	}
+	return checker
 }
 
 // RetryableFunc is a function that can be retried
 type RetryableFunc func() error
 
+// ErrStop can be returned (or wrapped) by a retried function to stop retrying
+// and report success, e.g. when it finds the work was already done elsewhere.
+var ErrStop = errors.New("ebo: stop retrying")
+
+// retryAfterError carries the delay requested by the retried function.
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// RetryAfter wraps err so that Retry waits exactly d (capped at Max) before
+// the next attempt instead of the computed backoff. Later attempts go back to
+// the normal schedule. This is the function-level counterpart of honoring an
+// HTTP Retry-After header, e.g. when the wait time comes in a response payload.
+//
+// Example:
+//
+//	err := ebo.Retry(func() error {
+//	    res, err := submitJob()
+//	    if err != nil {
+//	        return err
+//	    }
+//	    if res.Busy {
+//	        return ebo.RetryAfter(errors.New("worker busy"), res.RetryIn)
+//	    }
+//	    return nil
+//	})
+func RetryAfter(err error, d time.Duration) error {
+	return &retryAfterError{err: err, delay: d}
+}
+
 // Retry executes the given function with exponential backoff.
 // It will retry the function until it succeeds, reaches the maximum retry limit,
 // or the maximum elapsed time is exceeded.
 //
+// The error returned by fn decides what happens next:
+//   - nil: success, Retry returns nil
+//   - ErrStop: retrying stops and Retry returns nil
+//   - a permanent error (see RetryWithCondition and WithClassifier): retrying stops and the error is returned
+//   - any other error: fn is retried after the next backoff interval
+//
 // Example:
 //
 //	err := ebo.Retry(func() error {
@@ -59,52 +473,161 @@ type RetryableFunc func() error
 //	    return nil
 //	}, ebo.Tries(5), ebo.Initial(1*time.Second))
 func Retry(fn RetryableFunc, opts ...Option) error {
-	config := &RetryConfig{
-		InitialInterval: defaultInitialInterval,
-		MaxInterval:     defaultMaxInterval,
-		MaxRetries:      defaultMaxRetries,
-		Multiplier:      defaultMultiplier,
-		MaxElapsedTime:  defaultMaxElapsedTime,
-		RandomizeFactor: defaultRandomizeFactor,
+	if len(opts) == 0 && defaultOptions.Load() == nil {
+		// Keep the configuration on the stack for the common no-options call
+		config := defaultConfig()
+		return retry(&config, fn)
 	}
+	return retry(newConfig(opts...), fn)
+}
 
-	for _, opt := range opts {
-		opt(config)
-	}
+// RetryCtx is the recommended context-aware form of Retry. It stops as soon
+// as ctx is done, including while sleeping between attempts, and returns
+// ctx.Err(). It is equivalent to Retry with WithContext(ctx).
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//
+//	err := ebo.RetryCtx(ctx, func() error {
+//	    return performLongOperation()
+//	}, ebo.Tries(10), ebo.Initial(1*time.Second))
+func RetryCtx(ctx context.Context, fn RetryableFunc, opts ...Option) error {
+	return Retry(fn, append(opts[:len(opts):len(opts)], WithContext(ctx))...)
+}
+
+// retry runs the retry loop for fn using an already built configuration.
+func retry(config *RetryConfig, fn RetryableFunc) error {
+	_, err := retryWithReason(config, fn)
+	return err
+}
 
+// retryWithReason runs the retry loop and reports why it stopped.
+func retryWithReason(config *RetryConfig, fn RetryableFunc) (StopReason, error) {
+	ctx := config.context()
+	if config.onContextDone != nil {
+		defer watchContext(ctx, config.onContextDone)()
+	}
 	startTime := time.Now()
 	attempts := 0
-	currentInterval := config.InitialInterval
+	var slept time.Duration
+
+	// Wait out FirstDelay; it counts toward MaxElapsedTime but not MaxCumulativeDelay
+	if delay := config.delay(1); delay > 0 {
+		if waitErr := config.wait(ctx, delay); waitErr != nil {
+			if reason := waitReason(waitErr); reason != ReasonContextCancelled {
+				return config.giveUp(0, reason, stopError(reason, nil))
+			}
+			return config.giveUp(0, ReasonContextCancelled, waitErr)
+		}
+	}
 
+	var lastErr error
+	budgetMark := startTime
 	for {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return config.giveUp(attempts, ReasonContextCancelled, ctxErr)
+		}
+		if config.stopped() {
+			return config.giveUp(attempts, ReasonAborted, stopError(ReasonAborted, lastErr))
+		}
+		if config.budget != nil && config.budget.spendSince(&budgetMark) <= 0 {
+			return config.giveUp(attempts, ReasonMaxElapsed, stopError(ReasonMaxElapsed, lastErr))
+		}
+
+		attemptStart := time.Now()
+		var end func(error)
+		if config.tracer != nil {
+			_, end = config.startSpan(withRetryState(ctx, RetryState{
+				Attempt: attempts + 1,
+				Elapsed: attemptStart.Sub(startTime),
+			}))
+		}
 		err := fn()
-		if err == nil {
-			return nil
+		if end != nil {
+			end(err)
 		}
+		attemptDuration := time.Since(attemptStart)
+		attempts++
+		config.logAttempt(attempts, err)
 
-		// Check if the error is permanent and should not be retried
-		var permErr *permanentError
-		if errors.As(err, &permErr) {
-			return permErr.err
+		var budgetLeft time.Duration
+		if config.budget != nil {
+			budgetLeft = config.budget.spendSince(&budgetMark)
 		}
 
-		attempts++
+		if err == nil || errors.Is(err, ErrStop) {
+			return ReasonSuccess, nil
+		}
+		config.cleanupAttempt(attempts)
+
+		// Check if the error is permanent and should not be retried
+		if permErr, ok := config.permanent(err); ok {
+			return config.giveUp(attempts, ReasonPermanent, permErr)
+		}
 
 		if config.MaxRetries > 0 && attempts >= config.MaxRetries {
-			return err
+			return config.giveUp(attempts, ReasonMaxAttempts, err)
+		}
+		if config.reachedSafetyLimit(attempts) {
+			return config.giveUp(attempts, ReasonMaxAttempts, err)
 		}
 		if config.MaxElapsedTime > 0 && time.Since(startTime) >= config.MaxElapsedTime {
-			return err
+			return config.giveUp(attempts, ReasonMaxElapsed, err)
+		}
+		delay, jitter := config.statusDelay(err, attempts+1)
+		var after *retryAfterError
+		if errors.As(err, &after) && !config.noDelay {
+			delay, jitter = min(after.delay, config.MaxInterval), 0
+		}
+		if !config.deadline.IsZero() {
+			// Leave the next attempt twice as long as the last one took
+			remaining := time.Until(config.deadline) - 2*attemptDuration
+			if remaining <= 0 {
+				return config.giveUp(attempts, ReasonMaxElapsed, err)
+			}
+			delay = min(delay, remaining)
+		}
+		// Do not sleep into an exhausted time budget
+		if config.budget != nil && delay >= budgetLeft {
+			return config.giveUp(attempts, ReasonMaxElapsed, err)
+		}
+		if config.maxCumulativeDelay > 0 {
+			if slept+delay > config.maxCumulativeDelay {
+				return config.giveUp(attempts, ReasonMaxElapsed, err)
+			}
+			slept += delay
 		}
-		nextInterval := min(time.Duration(float64(currentInterval)*config.Multiplier), config.MaxInterval)
-		if config.RandomizeFactor > 0 {
-			delta := config.RandomizeFactor * float64(nextInterval)
-			minInterval := float64(nextInterval) - delta
-			maxInterval := float64(nextInterval) + delta
-			nextInterval = time.Duration(minInterval + (rand.Float64() * (maxInterval - minInterval)))
+		if config.takeRetry != nil && !config.takeRetry() {
+			return config.giveUp(attempts, ReasonMaxAttempts, err)
+		}
+		config.logRetry(attempts+1, delay)
+		if config.onRetry != nil {
+			config.onRetry(attempts+1, delay, jitter)
+		}
+		lastErr = err
+		if delay > 0 {
+			if waitErr := config.wait(ctx, delay); waitErr != nil {
+				if reason := waitReason(waitErr); reason != ReasonContextCancelled {
+					return config.giveUp(attempts, reason, stopError(reason, err))
+				}
+				return config.giveUp(attempts, ReasonContextCancelled, waitErr)
+			}
+		}
+
+		// Time spent paused counts toward neither MaxElapsedTime nor the time budget
+		if config.isPaused != nil {
+			paused, waitErr := config.pause(ctx)
+			if waitErr != nil {
+				if reason := waitReason(waitErr); reason != ReasonContextCancelled {
+					return config.giveUp(attempts, reason, stopError(reason, err))
+				}
+				return config.giveUp(attempts, ReasonContextCancelled, waitErr)
+			}
+			startTime = startTime.Add(paused)
+			budgetMark = budgetMark.Add(paused)
 		}
-		time.Sleep(currentInterval)
-		currentInterval = nextInterval
 	}
 }
 
@@ -124,12 +647,26 @@ func Retry(fn RetryableFunc, opts ...Option) error {
 //	    return checkServiceHealth()
 //	})
 func QuickRetry(fn RetryableFunc) error {
+	return QuickRetryContext(context.Background(), fn)
+}
+
+// QuickRetryContext is QuickRetry with cancellation support.
+// It stops as soon as ctx is done, including while waiting between attempts,
+// and returns ctx.Err().
+//
+// Example:
+//
+//	err := ebo.QuickRetryContext(r.Context(), func() error {
+//	    return checkServiceHealth()
+//	})
+func QuickRetryContext(ctx context.Context, fn RetryableFunc) error {
 	return Retry(fn,
 		Initial(100*time.Millisecond),
 		Max(5*time.Second),
 		Tries(5),
 		Multiplier(2.0),
 		Jitter(0.3),
+		WithContext(ctx),
 	)
 }
 
@@ -138,7 +675,7 @@ func QuickRetry(fn RetryableFunc) error {
 //
 // Parameters:
 // - Initial interval: 100ms
-// - Max interval: 10s
+// - Max interval: 10s (see RetryWithBackoffMax)
 // - Multiplier: 2.0
 //
 // Example:
@@ -147,18 +684,55 @@ func QuickRetry(fn RetryableFunc) error {
 //	    return performOperation()
 //	}, 3) // max 3 retries
 func RetryWithBackoff(fn RetryableFunc, maxRetries int) error {
-	backoff := 100 * time.Millisecond
-	maxBackoff := 10 * time.Second
+	return RetryWithBackoffContext(context.Background(), fn, maxRetries)
+}
+
+// RetryWithBackoffContext is RetryWithBackoff with cancellation support.
+// It stops as soon as ctx is done, including while waiting between attempts,
+// and returns ctx.Err().
+//
+// Example:
+//
+//	err := ebo.RetryWithBackoffContext(ctx, func() error {
+//	    return performOperation()
+//	}, 3) // max 3 retries
+func RetryWithBackoffContext(ctx context.Context, fn RetryableFunc, maxRetries int) error {
+	return retryWithBackoff(ctx, fn, maxRetries, defaultBackoffMax)
+}
+
+// RetryWithBackoffMax is RetryWithBackoff with the cap on the wait between
+// attempts set to maxBackoff instead of 10s.
+//
+// Example:
+//
+//	err := ebo.RetryWithBackoffMax(func() error {
+//	    return performOperation()
+//	}, 5, 500*time.Millisecond) // 100ms, 200ms, 400ms, 500ms between attempts
+func RetryWithBackoffMax(fn RetryableFunc, maxRetries int, maxBackoff time.Duration) error {
+	return retryWithBackoff(context.Background(), fn, maxRetries, maxBackoff)
+}
+
+// retryWithBackoff makes up to maxRetries attempts, waiting 100ms before the
+// second one and doubling the wait up to maxBackoff. There is no wait after
+// the last attempt.
+func retryWithBackoff(ctx context.Context, fn RetryableFunc, maxRetries int, maxBackoff time.Duration) error {
+	backoff := min(defaultBackoffInitial, maxBackoff)
 
 	for i := range maxRetries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		if err := fn(); err == nil {
 			return nil
 		} else if i == maxRetries-1 {
 			return err
 		}
 
-		time.Sleep(backoff)
-		backoff = time.Duration(math.Min(float64(backoff*2), float64(maxBackoff)))
+		if err := sleep(ctx, backoff); err != nil {
+			return err
+		}
+		backoff = min(backoff*2, maxBackoff)
 	}
 
 	return nil
@@ -1,7 +1,15 @@
 package ebo
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -137,4 +145,1070 @@ func TestRetry(t *testing.T) {
 			t.Errorf("expected 2 attempts, got %d", attempts)
 		}
 	})
+
+	t.Run("backoff max and no trailing sleep", func(t *testing.T) {
+		var calls []time.Time
+		start := time.Now()
+		err := RetryWithBackoffMax(func() error {
+			calls = append(calls, time.Now())
+			return errors.New("always fail")
+		}, 3, 120*time.Millisecond)
+		total := time.Since(start)
+
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if len(calls) != 3 {
+			t.Fatalf("expected 3 attempts, got %d", len(calls))
+		}
+
+		// 100ms, then 200ms capped at 120ms
+		wants := []time.Duration{100 * time.Millisecond, 120 * time.Millisecond}
+		var slept time.Duration
+		for i, want := range wants {
+			gap := calls[i+1].Sub(calls[i])
+			if gap < want || gap > want+50*time.Millisecond {
+				t.Errorf("gap %d: expected about %v, got %v", i+1, want, gap)
+			}
+			slept += gap
+		}
+
+		// The total is the sleeps between attempts, without one after the last
+		if total-slept > 50*time.Millisecond {
+			t.Errorf("expected total %v to match the sleeps between attempts %v", total, slept)
+		}
+	})
+}
+
+func TestNextInterval(t *testing.T) {
+	t.Run("applies multiplier and cap", func(t *testing.T) {
+		cfg := RetryConfig{
+			MaxInterval: 50 * time.Millisecond,
+			Multiplier:  2.0,
+		}
+
+		tests := []struct {
+			current time.Duration
+			want    time.Duration
+		}{
+			{10 * time.Millisecond, 20 * time.Millisecond},
+			{20 * time.Millisecond, 40 * time.Millisecond},
+			{40 * time.Millisecond, 50 * time.Millisecond},
+			{50 * time.Millisecond, 50 * time.Millisecond},
+		}
+
+		for _, tt := range tests {
+			if got := NextInterval(tt.current, cfg); got != tt.want {
+				t.Errorf("NextInterval(%v) = %v, want %v", tt.current, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("jitter stays within bounds", func(t *testing.T) {
+		cfg := RetryConfig{
+			MaxInterval:     time.Second,
+			Multiplier:      2.0,
+			RandomizeFactor: 0.5,
+		}
+
+		for range 100 {
+			got := NextInterval(100*time.Millisecond, cfg)
+			if got < 100*time.Millisecond || got > 300*time.Millisecond {
+				t.Fatalf("NextInterval() = %v, want between 100ms and 300ms", got)
+			}
+		}
+	})
+
+	t.Run("matches iterator delays", func(t *testing.T) {
+		opts := []Option{
+			Initial(time.Millisecond),
+			Max(5 * time.Millisecond),
+			Multiplier(2.0),
+			Tries(5),
+			NoJitter(),
+		}
+		cfg := newConfig(opts...)
+
+		expected := cfg.InitialInterval
+		for attempt := range Attempts(opts...) {
+			if attempt.Number == 1 {
+				continue
+			}
+			if attempt.Delay != expected {
+				t.Errorf("attempt %d: delay = %v, want %v", attempt.Number, attempt.Delay, expected)
+			}
+			expected = NextInterval(expected, *cfg)
+		}
+	})
+}
+
+func TestDefaultMaxAttempts(t *testing.T) {
+	original := DefaultMaxAttempts
+	defer func() { DefaultMaxAttempts = original }()
+	DefaultMaxAttempts = 5
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	unbounded := []Option{
+		Forever(),
+		MaxTime(0),
+		Initial(time.Millisecond),
+		Max(time.Millisecond),
+		NoJitter(),
+	}
+
+	t.Run("stops unbounded retry", func(t *testing.T) {
+		buf.Reset()
+		attempts := 0
+		err := Retry(func() error {
+			attempts++
+			return errors.New("always fail")
+		}, unbounded...)
+
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+		if attempts != 5 {
+			t.Errorf("expected 5 attempts, got %d", attempts)
+		}
+		if !strings.Contains(buf.String(), "DefaultMaxAttempts") {
+			t.Errorf("expected warning to be logged, got: %s", buf.String())
+		}
+	})
+
+	t.Run("stops unbounded iterator", func(t *testing.T) {
+		attempts := 0
+		for range Attempts(unbounded...) {
+			attempts++
+		}
+		if attempts != 5 {
+			t.Errorf("expected 5 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("does not apply when bounded by time", func(t *testing.T) {
+		attempts := 0
+		_ = Retry(func() error {
+			attempts++
+			return errors.New("always fail")
+		}, Forever(), MaxTime(100*time.Millisecond), Initial(time.Millisecond), Max(time.Millisecond))
+
+		if attempts <= 5 {
+			t.Errorf("expected more than 5 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("disabled when zero", func(t *testing.T) {
+		DefaultMaxAttempts = 0
+		defer func() { DefaultMaxAttempts = 5 }()
+
+		attempts := 0
+		_ = Retry(func() error {
+			attempts++
+			if attempts < 10 {
+				return errors.New("fail")
+			}
+			return nil
+		}, unbounded...)
+
+		if attempts != 10 {
+			t.Errorf("expected 10 attempts, got %d", attempts)
+		}
+	})
+}
+
+func TestErrStop(t *testing.T) {
+	t.Run("stop reports success", func(t *testing.T) {
+		attempts := 0
+		err := Retry(func() error {
+			attempts++
+			if attempts == 2 {
+				return ErrStop
+			}
+			return errors.New("temporary error")
+		}, Initial(10*time.Millisecond), Tries(5))
+
+		if err != nil {
+			t.Errorf("expected nil error, got: %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("wrapped stop reports success", func(t *testing.T) {
+		attempts := 0
+		err := Retry(func() error {
+			attempts++
+			return fmt.Errorf("already migrated: %w", ErrStop)
+		}, Initial(10*time.Millisecond), Tries(5))
+
+		if err != nil {
+			t.Errorf("expected nil error, got: %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("iterator stop reports success", func(t *testing.T) {
+		attempts := 0
+		err := DoWithAttempts(func(*Attempt) error {
+			attempts++
+			return ErrStop
+		}, Tries(5))
+
+		if err != nil {
+			t.Errorf("expected nil error, got: %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("value is delivered on stop", func(t *testing.T) {
+		res := <-RetryValueAsync(func() (string, error) {
+			return "cached", ErrStop
+		}, Tries(5))
+
+		if res.Err != nil {
+			t.Errorf("expected nil error, got: %v", res.Err)
+		}
+		if res.Value != "cached" {
+			t.Errorf("expected value 'cached', got %q", res.Value)
+		}
+	})
+}
+
+func TestImmediateFirstRetry(t *testing.T) {
+	var calls []time.Time
+	err := Retry(func() error {
+		calls = append(calls, time.Now())
+		if len(calls) < 3 {
+			return errors.New("temporary error")
+		}
+		return nil
+	}, ImmediateFirstRetry(), Initial(50*time.Millisecond), NoJitter())
+
+	if err != nil {
+		t.Fatalf("expected success, got error: %v", err)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(calls))
+	}
+	if gap := calls[1].Sub(calls[0]); gap >= 25*time.Millisecond {
+		t.Errorf("expected immediate second attempt, waited %v", gap)
+	}
+	if gap := calls[2].Sub(calls[1]); gap < 50*time.Millisecond {
+		t.Errorf("expected third attempt to wait at least 50ms, waited %v", gap)
+	}
+}
+
+func TestFirstDelay(t *testing.T) {
+	t.Run("delays the first call", func(t *testing.T) {
+		start := time.Now()
+		var first time.Time
+		err := Retry(func() error {
+			if first.IsZero() {
+				first = time.Now()
+			}
+			return nil
+		}, FirstDelay(30*time.Millisecond))
+
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		if gap := first.Sub(start); gap < 30*time.Millisecond {
+			t.Errorf("expected first call after at least 30ms, got %v", gap)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		start := time.Now()
+		for attempt := range Attempts(FirstDelay(30*time.Millisecond), Tries(1)) {
+			if attempt.Delay != 30*time.Millisecond {
+				t.Errorf("expected first attempt delay 30ms, got %v", attempt.Delay)
+			}
+			if gap := time.Since(start); gap < 30*time.Millisecond {
+				t.Errorf("expected first attempt after at least 30ms, got %v", gap)
+			}
+		}
+	})
+
+	t.Run("cancellable", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		calls := 0
+		start := time.Now()
+		err := RetryCtx(ctx, func() error {
+			calls++
+			return nil
+		}, FirstDelay(time.Second))
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if calls != 0 {
+			t.Errorf("expected no calls, got %d", calls)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("expected the wait to be interrupted, took %v", elapsed)
+		}
+	})
+
+	t.Run("counts toward max elapsed time", func(t *testing.T) {
+		calls := 0
+		err := Retry(func() error {
+			calls++
+			return errors.New("temporary error")
+		}, Forever(), FirstDelay(50*time.Millisecond), MaxTime(40*time.Millisecond), Initial(time.Millisecond))
+
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call once MaxTime was used up by the first delay, got %d", calls)
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Run("overrides the next delay only", func(t *testing.T) {
+		var calls []time.Time
+		busy := errors.New("busy")
+
+		err := Retry(func() error {
+			calls = append(calls, time.Now())
+			switch len(calls) {
+			case 1:
+				return RetryAfter(busy, 80*time.Millisecond)
+			case 2:
+				return errors.New("temporary error")
+			}
+			return nil
+		}, Initial(5*time.Millisecond), NoJitter())
+
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		if len(calls) != 3 {
+			t.Fatalf("expected 3 attempts, got %d", len(calls))
+		}
+		if gap := calls[1].Sub(calls[0]); gap < 80*time.Millisecond {
+			t.Errorf("expected second attempt after at least 80ms, waited %v", gap)
+		}
+		if gap := calls[2].Sub(calls[1]); gap >= 50*time.Millisecond {
+			t.Errorf("expected third attempt to use normal 10ms backoff, waited %v", gap)
+		}
+	})
+
+	t.Run("clamped to max", func(t *testing.T) {
+		start := time.Now()
+		attempts := 0
+
+		_ = Retry(func() error {
+			attempts++
+			if attempts == 1 {
+				return RetryAfter(errors.New("busy"), time.Hour)
+			}
+			return nil
+		}, Max(20*time.Millisecond))
+
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("expected delay capped at 20ms, took %v", elapsed)
+		}
+	})
+
+	t.Run("wrapped error is preserved", func(t *testing.T) {
+		busy := errors.New("busy")
+		err := Retry(func() error {
+			return RetryAfter(busy, time.Millisecond)
+		}, Tries(2))
+
+		if !errors.Is(err, busy) {
+			t.Errorf("expected %v, got %v", busy, err)
+		}
+		if err.Error() != "busy" {
+			t.Errorf("expected message 'busy', got %q", err.Error())
+		}
+	})
+}
+
+func TestContextAwareShortcuts(t *testing.T) {
+	t.Run("QuickRetryContext cancelled during backoff", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+
+		attempts := 0
+		start := time.Now()
+		err := QuickRetryContext(ctx, func() error {
+			attempts++
+			return errors.New("always fail")
+		})
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+		if elapsed := time.Since(start); elapsed > 70*time.Millisecond {
+			t.Errorf("expected backoff sleep to be interrupted, took %v", elapsed)
+		}
+	})
+
+	t.Run("RetryWithBackoffContext cancelled during backoff", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+
+		attempts := 0
+		start := time.Now()
+		err := RetryWithBackoffContext(ctx, func() error {
+			attempts++
+			return errors.New("always fail")
+		}, 5)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+		if elapsed := time.Since(start); elapsed > 70*time.Millisecond {
+			t.Errorf("expected backoff sleep to be interrupted, took %v", elapsed)
+		}
+	})
+
+	t.Run("RetryWithBackoffContext succeeds", func(t *testing.T) {
+		attempts := 0
+		err := RetryWithBackoffContext(context.Background(), func() error {
+			attempts++
+			if attempts < 2 {
+				return errors.New("temporary error")
+			}
+			return nil
+		}, 3)
+
+		if err != nil {
+			t.Errorf("expected success, got error: %v", err)
+		}
+	})
+}
+
+func TestWithSleepHook(t *testing.T) {
+	t.Run("hooks bracket each sleep", func(t *testing.T) {
+		var events []string
+		attempts := 0
+
+		err := Retry(func() error {
+			attempts++
+			events = append(events, fmt.Sprintf("attempt %d", attempts))
+			if attempts < 3 {
+				return errors.New("temporary error")
+			}
+			return nil
+		}, Initial(time.Millisecond), WithSleepHook(
+			func() { events = append(events, "before") },
+			func() { events = append(events, "after") },
+		))
+
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		want := []string{"attempt 1", "before", "after", "attempt 2", "before", "after", "attempt 3"}
+		if strings.Join(events, ",") != strings.Join(want, ",") {
+			t.Errorf("expected events %v, got %v", want, events)
+		}
+	})
+
+	t.Run("releases semaphore while sleeping", func(t *testing.T) {
+		sem := make(chan struct{}, 1)
+		sem <- struct{}{}
+		heldDuringSleep := true
+
+		attempts := 0
+		_ = Retry(func() error {
+			attempts++
+			if len(sem) != 1 {
+				t.Errorf("attempt %d ran without holding the slot", attempts)
+			}
+			return errors.New("fail")
+		}, Tries(2), Initial(5*time.Millisecond), WithSleepHook(
+			func() {
+				<-sem
+				heldDuringSleep = len(sem) != 0
+			},
+			func() { sem <- struct{}{} },
+		))
+
+		if heldDuringSleep {
+			t.Error("expected slot to be released during sleep")
+		}
+	})
+
+	t.Run("iterator runs hooks", func(t *testing.T) {
+		before, after := 0, 0
+		for range Attempts(Tries(3), Initial(time.Millisecond), WithSleepHook(
+			func() { before++ },
+			func() { after++ },
+		)) {
+		}
+
+		if before != 2 || after != 2 {
+			t.Errorf("expected 2 hook calls each, got before=%d after=%d", before, after)
+		}
+	})
+}
+
+func TestOnCleanup(t *testing.T) {
+	t.Run("runs before each sleep", func(t *testing.T) {
+		var events []string
+		attempts := 0
+
+		err := Retry(func() error {
+			attempts++
+			events = append(events, fmt.Sprintf("attempt %d", attempts))
+			if attempts < 3 {
+				return errors.New("temporary error")
+			}
+			return nil
+		}, Initial(time.Millisecond),
+			OnCleanup(func(attempt int) { events = append(events, fmt.Sprintf("cleanup %d", attempt)) }),
+			WithSleepHook(func() { events = append(events, "sleep") }, nil),
+		)
+
+		if err != nil {
+			t.Fatalf("expected success, got error: %v", err)
+		}
+		want := []string{"attempt 1", "cleanup 1", "sleep", "attempt 2", "cleanup 2", "sleep", "attempt 3"}
+		if strings.Join(events, ",") != strings.Join(want, ",") {
+			t.Errorf("expected events %v, got %v", want, events)
+		}
+	})
+
+	t.Run("runs once on give up", func(t *testing.T) {
+		var cleaned []int
+		err := Retry(func() error {
+			return errors.New("always fail")
+		}, Tries(3), Initial(time.Millisecond), OnCleanup(func(attempt int) { cleaned = append(cleaned, attempt) }))
+
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if fmt.Sprint(cleaned) != "[1 2 3]" {
+			t.Errorf("expected cleanups [1 2 3], got %v", cleaned)
+		}
+	})
+
+	t.Run("runs on cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var cleaned []int
+		err := Retry(func() error {
+			cancel()
+			return errors.New("fail")
+		}, Initial(time.Second), WithContext(ctx), OnCleanup(func(attempt int) { cleaned = append(cleaned, attempt) }))
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if fmt.Sprint(cleaned) != "[1]" {
+			t.Errorf("expected cleanups [1], got %v", cleaned)
+		}
+	})
+
+	t.Run("DoWithAttempts", func(t *testing.T) {
+		var cleaned []int
+		_ = DoWithAttempts(func(attempt *Attempt) error {
+			return &permanentError{errors.New("fatal")}
+		}, Tries(3), OnCleanup(func(attempt int) { cleaned = append(cleaned, attempt) }))
+
+		if fmt.Sprint(cleaned) != "[1]" {
+			t.Errorf("expected cleanups [1], got %v", cleaned)
+		}
+	})
+}
+
+func TestPermanentErrorUnwrapped(t *testing.T) {
+	fatal := errors.New("fatal")
+	permanent := func() error { return &permanentError{fatal} }
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"Retry", func() error { return Retry(permanent, Tries(3)) }},
+		{"RetryCtx", func() error { return RetryCtx(context.Background(), permanent, Tries(3)) }},
+		{"DoWithAttempts", func() error {
+			return DoWithAttempts(func(*Attempt) error { return permanent() }, Tries(3))
+		}},
+		{"DoWithAttemptsContext", func() error {
+			return DoWithAttemptsContext(context.Background(), func(*Attempt) error { return permanent() }, Tries(3))
+		}},
+		{"wrapped", func() error {
+			return DoWithAttempts(func(*Attempt) error { return fmt.Errorf("save: %w", permanent()) }, Tries(3))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if err == nil || err.Error() != fatal.Error() {
+				t.Errorf("expected %q, got %v", fatal, err)
+			}
+		})
+	}
+}
+
+func TestRetryCtx(t *testing.T) {
+	t.Run("cancellation interrupts sleep", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		attempts := 0
+		start := time.Now()
+		err := RetryCtx(ctx, func() error {
+			attempts++
+			return errors.New("always fail")
+		}, Initial(time.Second), NoJitter())
+		elapsed := time.Since(start)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+		if elapsed > 200*time.Millisecond {
+			t.Errorf("expected to return soon after cancellation, took %v", elapsed)
+		}
+	})
+
+	t.Run("RetryWithContext alias", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := RetryWithContext(ctx, func() error {
+			return errors.New("always fail")
+		}, Initial(time.Second), NoJitter())
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+			t.Errorf("expected to return soon after the deadline, took %v", elapsed)
+		}
+	})
+}
+
+func TestMaxCumulativeDelay(t *testing.T) {
+	// Backoffs of 1, 2, 4 and 8ms sum to 15ms; the next 16ms would exceed 20ms
+	opts := []Option{Initial(time.Millisecond), Multiplier(2), NoJitter(), Tries(20), MaxCumulativeDelay(20 * time.Millisecond)}
+
+	t.Run("Retry", func(t *testing.T) {
+		attempts := 0
+		reason, err := RetryWithReason(func() error {
+			attempts++
+			time.Sleep(5 * time.Millisecond) // Execution time does not count
+			return errors.New("always fail")
+		}, opts...)
+
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if reason != ReasonMaxElapsed {
+			t.Errorf("expected ReasonMaxElapsed, got %v", reason)
+		}
+		if attempts != 5 {
+			t.Errorf("expected 5 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		var slept time.Duration
+		attempts := 0
+		for attempt := range Attempts(opts...) {
+			attempts++
+			slept += attempt.Delay
+		}
+
+		if attempts != 5 {
+			t.Errorf("expected 5 attempts, got %d", attempts)
+		}
+		if slept != 15*time.Millisecond {
+			t.Errorf("expected 15ms of backoff, got %v", slept)
+		}
+	})
+}
+
+func BenchmarkRetryFirstTrySuccess(b *testing.B) {
+	fn := func() error { return nil }
+
+	b.Run("no options", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_ = Retry(fn)
+		}
+	})
+
+	b.Run("options", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_ = Retry(fn, Tries(3), Initial(time.Millisecond))
+		}
+	})
+
+	b.Run("Retrier", func(b *testing.B) {
+		r := NewRetrier(Tries(3), Initial(time.Millisecond))
+		b.ReportAllocs()
+		for range b.N {
+			_ = r.Retry(fn)
+		}
+	})
+}
+
+func TestSetDefaults(t *testing.T) {
+	t.Cleanup(func() { SetDefaults() })
+	SetDefaults(Tries(2), Initial(time.Millisecond), NoJitter())
+
+	t.Run("defaults apply when omitted", func(t *testing.T) {
+		attempts := 0
+		_ = Retry(func() error {
+			attempts++
+			return errors.New("always fail")
+		})
+
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts from defaults, got %d", attempts)
+		}
+		if config := newConfig(); config.InitialInterval != time.Millisecond || config.RandomizeFactor != 0 {
+			t.Errorf("expected defaults in config, got initial=%v jitter=%v", config.InitialInterval, config.RandomizeFactor)
+		}
+	})
+
+	t.Run("call-site options win", func(t *testing.T) {
+		attempts := 0
+		_ = Retry(func() error {
+			attempts++
+			return errors.New("always fail")
+		}, Tries(4))
+
+		if attempts != 4 {
+			t.Errorf("expected 4 attempts from call site, got %d", attempts)
+		}
+	})
+
+	t.Run("Retrier uses defaults", func(t *testing.T) {
+		if got := NewRetrier().BaseInterval(); got != time.Millisecond {
+			t.Errorf("expected 1ms base interval, got %v", got)
+		}
+	})
+
+	t.Run("clearing restores built-in defaults", func(t *testing.T) {
+		SetDefaults()
+		defer SetDefaults(Tries(2), Initial(time.Millisecond), NoJitter())
+
+		if config := newConfig(); config.MaxRetries != defaultMaxRetries {
+			t.Errorf("expected %d retries, got %d", defaultMaxRetries, config.MaxRetries)
+		}
+	})
+
+	t.Run("concurrent reads", func(t *testing.T) {
+		done := make(chan struct{})
+		for range 8 {
+			go func() {
+				defer func() { done <- struct{}{} }()
+				for range 100 {
+					_ = newConfig()
+				}
+			}()
+		}
+		for range 8 {
+			<-done
+		}
+	})
+}
+
+func TestWithStopChan(t *testing.T) {
+	t.Run("closing interrupts sleep", func(t *testing.T) {
+		stop := make(chan struct{})
+		time.AfterFunc(20*time.Millisecond, func() { close(stop) })
+
+		lastErr := errors.New("still failing")
+		attempts := 0
+		start := time.Now()
+		reason, err := RetryWithReason(func() error {
+			attempts++
+			return lastErr
+		}, Initial(time.Second), NoJitter(), WithStopChan(stop))
+		elapsed := time.Since(start)
+
+		if !errors.Is(err, ErrAborted) || !errors.Is(err, lastErr) {
+			t.Errorf("expected ErrAborted wrapping the last error, got %v", err)
+		}
+		if reason != ReasonAborted {
+			t.Errorf("expected ReasonAborted, got %v", reason)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+		if elapsed > 200*time.Millisecond {
+			t.Errorf("expected prompt return, took %v", elapsed)
+		}
+	})
+
+	t.Run("send aborts", func(t *testing.T) {
+		stop := make(chan struct{})
+		go func() { stop <- struct{}{} }()
+
+		err := Retry(func() error {
+			return errors.New("fail")
+		}, Initial(time.Second), WithStopChan(stop))
+
+		if !errors.Is(err, ErrAborted) {
+			t.Errorf("expected ErrAborted, got %v", err)
+		}
+	})
+
+	t.Run("already closed", func(t *testing.T) {
+		stop := make(chan struct{})
+		close(stop)
+
+		calls := 0
+		err := Retry(func() error {
+			calls++
+			return nil
+		}, WithStopChan(stop))
+
+		if !errors.Is(err, ErrAborted) || calls != 0 {
+			t.Errorf("expected ErrAborted without calls, got %v after %d calls", err, calls)
+		}
+	})
+
+	t.Run("DoWithAttempts", func(t *testing.T) {
+		stop := make(chan struct{})
+		time.AfterFunc(20*time.Millisecond, func() { close(stop) })
+
+		start := time.Now()
+		err := DoWithAttempts(func(*Attempt) error {
+			return errors.New("fail")
+		}, Initial(time.Second), WithStopChan(stop))
+
+		if !errors.Is(err, ErrAborted) {
+			t.Errorf("expected ErrAborted, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+			t.Errorf("expected prompt return, took %v", elapsed)
+		}
+	})
+}
+
+func TestMaxTimeRunsOneAttempt(t *testing.T) {
+	t.Run("retry", func(t *testing.T) {
+		attempts := 0
+		err := Retry(func() error {
+			attempts++
+			return errors.New("temporary")
+		}, MaxTime(1*time.Nanosecond), Initial(time.Millisecond))
+
+		if err == nil || attempts != 1 {
+			t.Errorf("expected exactly one failed attempt, got %d and %v", attempts, err)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		attempts := 0
+		for range Attempts(MaxTime(1*time.Nanosecond), Initial(time.Millisecond)) {
+			attempts++
+		}
+		if attempts != 1 {
+			t.Errorf("expected exactly one attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("iterator with first delay", func(t *testing.T) {
+		attempts := 0
+		for range Attempts(MaxTime(1*time.Nanosecond), FirstDelay(time.Millisecond)) {
+			attempts++
+		}
+		if attempts != 1 {
+			t.Errorf("expected exactly one attempt, got %d", attempts)
+		}
+	})
+}
+
+// countingClock is a Clock that tracks how many sleeps are in progress.
+type countingClock struct {
+	mu      sync.Mutex
+	current int
+	peak    int
+}
+
+func (c *countingClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.current++
+	c.peak = max(c.peak, c.current)
+	c.mu.Unlock()
+
+	fired := make(chan time.Time, 1)
+	go func() {
+		time.Sleep(d)
+		c.mu.Lock()
+		c.current--
+		c.mu.Unlock()
+		fired <- time.Now()
+	}()
+	return fired
+}
+
+func TestSetMaxConcurrentBackoffs(t *testing.T) {
+	t.Cleanup(func() { SetMaxConcurrentBackoffs(0) })
+
+	t.Run("sleeps never exceed the limit", func(t *testing.T) {
+		SetMaxConcurrentBackoffs(2)
+
+		clock := &countingClock{}
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				attempts := 0
+				_ = Retry(func() error {
+					if attempts++; attempts < 3 {
+						return errors.New("temporary")
+					}
+					return nil
+				}, Initial(2*time.Millisecond), NoJitter(), Tries(3), WithClock(clock))
+			}()
+		}
+		wg.Wait()
+
+		if peak := clock.peak; peak > 2 || peak == 0 {
+			t.Errorf("expected at most 2 concurrent sleeps, got %d", peak)
+		}
+	})
+
+	t.Run("fail instead of waiting", func(t *testing.T) {
+		SetMaxConcurrentBackoffs(1)
+
+		// Hold the only slot
+		holder := newStepClock()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = Retry(func() error { return errors.New("temporary") }, Tries(2), WithClock(holder))
+		}()
+		<-holder.sleeps
+
+		attempts := 0
+		reason, err := RetryWithReason(func() error {
+			attempts++
+			return errors.New("temporary")
+		}, Initial(time.Millisecond), Tries(3), FailOnBackoffLimit())
+
+		if reason != ReasonBackoffLimit || !errors.Is(err, ErrBackoffLimit) || attempts != 1 {
+			t.Errorf("expected to give up after 1 attempt with ErrBackoffLimit, got %v, %v after %d", reason, err, attempts)
+		}
+
+		holder.fire <- time.Now()
+		<-done
+	})
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		SetMaxConcurrentBackoffs(0)
+
+		attempts := 0
+		err := Retry(func() error {
+			if attempts++; attempts < 3 {
+				return errors.New("temporary")
+			}
+			return nil
+		}, Initial(time.Millisecond), Tries(3), FailOnBackoffLimit())
+		if err != nil || attempts != 3 {
+			t.Errorf("expected success on attempt 3, got %v after %d", err, attempts)
+		}
+	})
+}
+
+func TestWithPause(t *testing.T) {
+	// pauseFor reports paused from the time start is called until d has passed
+	pauseFor := func(d time.Duration) (start func(), isPaused func() bool) {
+		var until atomic.Int64
+		start = func() { until.Store(time.Now().Add(d).UnixNano()) }
+		isPaused = func() bool { return time.Now().UnixNano() < until.Load() }
+		return start, isPaused
+	}
+	opts := func(isPaused func() bool) []Option {
+		return []Option{Initial(5 * time.Millisecond), Linear(), NoJitter(), Tries(10), MaxTime(60 * time.Millisecond), WithPause(isPaused, time.Millisecond)}
+	}
+
+	t.Run("paused time is not elapsed time", func(t *testing.T) {
+		startPause, isPaused := pauseFor(100 * time.Millisecond)
+		attempts := 0
+		start := time.Now()
+		err := Retry(func() error {
+			if attempts++; attempts == 2 {
+				startPause()
+			}
+			if attempts < 4 {
+				return errors.New("temporary")
+			}
+			return nil
+		}, opts(isPaused)...)
+
+		if err != nil || attempts != 4 {
+			t.Errorf("expected success on attempt 4 despite MaxTime, got %v after %d", err, attempts)
+		}
+		if took := time.Since(start); took < 100*time.Millisecond {
+			t.Errorf("expected to wait out the pause, took %v", took)
+		}
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		startPause, isPaused := pauseFor(100 * time.Millisecond)
+		count := 0
+		var elapsed time.Duration
+		for attempt := range Attempts(opts(isPaused)...) {
+			count++
+			state, _ := FromContext(attempt.Context)
+			elapsed = state.Elapsed
+			if attempt.Number == 2 {
+				startPause()
+			}
+			if attempt.Number == 4 {
+				break
+			}
+		}
+
+		if count != 4 {
+			t.Errorf("expected 4 attempts despite MaxTime, got %d", count)
+		}
+		if elapsed >= 60*time.Millisecond {
+			t.Errorf("expected elapsed to exclude the pause, got %v", elapsed)
+		}
+	})
+
+	t.Run("cancel while paused", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := RetryCtx(ctx, func() error {
+			return errors.New("temporary")
+		}, WithPause(func() bool { return true }, time.Hour), Initial(time.Millisecond), Tries(3))
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if took := time.Since(start); took > time.Second {
+			t.Errorf("expected cancellation to end the pause, took %v", took)
+		}
+	})
 }
@@ -0,0 +1,145 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Reconnect keeps a connection, such as a WebSocket, open until ctx is done.
+// It dials with the backoff from opts; once a dial succeeds, it waits for the
+// connection to drop and dials again with a fresh schedule, so drops spread
+// over time do not use up Tries. A connection signals its drop with a
+// Done() <-chan struct{} method, closed when it is lost; one without it is
+// held until ctx is done. Dropped connections are closed before redialing.
+//
+// Reconnect returns the dial error once retrying gives up, or ctx.Err()
+// after closing the current connection when ctx is done. A dial returning
+// ErrStop ends it with nil, and one returning neither a connection nor an
+// error is a bug reported without retrying.
+//
+// Example:
+//
+//	err := ebo.Reconnect(ctx, func(ctx context.Context) (io.Closer, error) {
+//	    conn, _, err := websocket.Dial(ctx, "wss://feed.example.com", nil)
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    session := startSession(conn) // Closes session.Done() when the read loop ends
+//	    return session, nil
+//	}, ebo.Forever(), ebo.Max(30*time.Second))
+func Reconnect(ctx context.Context, dial func(ctx context.Context) (io.Closer, error), opts ...Option) error {
+	config := newConfig(append(opts[:len(opts):len(opts)], WithContext(ctx))...)
+
+	for {
+		var conn io.Closer
+		err := retry(config, func() error {
+			var err error
+			conn, err = dial(ctx)
+			if err == nil && conn == nil {
+				return &permanentError{errNilConn}
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if conn == nil {
+			// The dial returned ErrStop
+			return nil
+		}
+
+		var dropped <-chan struct{}
+		if d, ok := conn.(interface{ Done() <-chan struct{} }); ok {
+			dropped = d.Done()
+		}
+		select {
+		case <-dropped:
+			_ = conn.Close()
+		case <-ctx.Done():
+			_ = conn.Close()
+			return ctx.Err()
+		}
+	}
+}
+
+// errNilConn is returned by Reconnect when dial returns a nil connection
+// without an error.
+var errNilConn = errors.New("ebo: dial returned neither a connection nor an error")
+
+// ResumeStream consumes a resumable stream, such as a server-streaming gRPC
+// call, reconnecting with backoff when it drops. open (re)opens the stream,
+// from token when it is not nil: nil on the first call, the token of the last
+// message read on reconnects. read receives and handles one message and
+// returns its resume token; io.EOF ends the stream and ResumeStream returns nil.
+//
+// Failures to open and read errors are retried with the backoff from opts.
+// Once the stream has delivered a message, a drop reconnects right away with a
+// fresh schedule, so a long-lived stream is not given up on because of drops
+// spread over time.
+// Errors are classified like with Retry, so a stream is only resumed on the
+// errors that are worth it with WithClassifier or WithPermanentDetector.
+// ctx is passed to open and interrupts the backoff sleeps.
+//
+// Example:
+//
+//	err := ebo.ResumeStream(ctx,
+//	    func(ctx context.Context, token *string) (pb.Feed_WatchClient, error) {
+//	        req := &pb.WatchRequest{}
+//	        if token != nil {
+//	            req.ResumeToken = *token
+//	        }
+//	        return client.Watch(ctx, req)
+//	    },
+//	    func(stream pb.Feed_WatchClient) (string, error) {
+//	        event, err := stream.Recv()
+//	        if err != nil {
+//	            return "", err
+//	        }
+//	        apply(event)
+//	        return event.ResumeToken, nil
+//	    },
+//	    ebo.API(),
+//	    ebo.WithPermanentDetector(func(err error) bool {
+//	        return status.Code(err) != codes.Unavailable
+//	    }),
+//	)
+func ResumeStream[S, T any](ctx context.Context, open func(ctx context.Context, token *T) (S, error), read func(S) (T, error), opts ...Option) error {
+	config := newConfig(append(opts[:len(opts):len(opts)], WithContext(ctx))...)
+
+	var token *T
+	for {
+		var dropped error
+		err := retry(config, func() error {
+			stream, err := open(ctx, token)
+			if err != nil {
+				return err
+			}
+
+			for progressed := false; ; progressed = true {
+				next, err := read(stream)
+				switch {
+				case errors.Is(err, io.EOF):
+					return nil
+				case err != nil && progressed:
+					// Leave this schedule to reconnect with a fresh one
+					dropped = err
+					return nil
+				case err != nil:
+					return err
+				}
+				token = &next
+			}
+		})
+		if err != nil || dropped == nil {
+			return err
+		}
+
+		if err, stop := config.permanent(dropped); stop {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
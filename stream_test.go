@@ -0,0 +1,238 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeStream serves messages from a position, dropping with err after dropAfter messages.
+type fakeStream struct {
+	messages  []string
+	pos       int
+	dropAfter int
+	err       error
+}
+
+func (s *fakeStream) recv() (string, error) {
+	if s.dropAfter == 0 {
+		return "", s.err
+	}
+	s.dropAfter--
+	if s.pos == len(s.messages) {
+		return "", io.EOF
+	}
+	s.pos++
+	return s.messages[s.pos-1], nil
+}
+
+func TestResumeStream(t *testing.T) {
+	messages := []string{"a", "b", "c", "d", "e"}
+	unavailable := errors.New("unavailable")
+
+	t.Run("resumes from the last token", func(t *testing.T) {
+		var tokens []string
+		var received []string
+		opens := 0
+
+		err := ResumeStream(context.Background(),
+			func(_ context.Context, token *string) (*fakeStream, error) {
+				opens++
+				stream := &fakeStream{messages: messages, dropAfter: -1}
+				if token == nil {
+					tokens = append(tokens, "")
+					stream.dropAfter, stream.err = 2, unavailable
+					return stream, nil
+				}
+				tokens = append(tokens, *token)
+				stream.pos = slices.Index(messages, *token) + 1
+				return stream, nil
+			},
+			func(stream *fakeStream) (string, error) {
+				msg, err := stream.recv()
+				if err != nil {
+					return "", err
+				}
+				received = append(received, msg)
+				return msg, nil
+			},
+			Initial(time.Millisecond), Tries(2),
+		)
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if opens != 2 || !slices.Equal(tokens, []string{"", "b"}) {
+			t.Errorf("expected a reopen from token b, got opens %q", tokens)
+		}
+		if !slices.Equal(received, messages) {
+			t.Errorf("expected every message once, got %v", received)
+		}
+	})
+
+	t.Run("open failures back off", func(t *testing.T) {
+		opens := 0
+		err := ResumeStream(context.Background(),
+			func(_ context.Context, _ *string) (*fakeStream, error) {
+				opens++
+				if opens < 3 {
+					return nil, unavailable
+				}
+				return &fakeStream{messages: messages, dropAfter: -1}, nil
+			},
+			func(stream *fakeStream) (string, error) {
+				return stream.recv()
+			},
+			Initial(time.Millisecond), Tries(3),
+		)
+
+		if err != nil || opens != 3 {
+			t.Errorf("expected success on the third open, got %d opens and %v", opens, err)
+		}
+	})
+
+	t.Run("permanent drop stops", func(t *testing.T) {
+		denied := errors.New("permission denied")
+		opens := 0
+		err := ResumeStream(context.Background(),
+			func(_ context.Context, _ *string) (*fakeStream, error) {
+				opens++
+				return &fakeStream{messages: messages, dropAfter: 1, err: denied}, nil
+			},
+			func(stream *fakeStream) (string, error) {
+				return stream.recv()
+			},
+			Initial(time.Millisecond), Tries(3),
+			WithPermanentDetector(func(err error) bool {
+				return errors.Is(err, denied)
+			}),
+		)
+
+		if !errors.Is(err, denied) || opens != 1 {
+			t.Errorf("expected permission denied after one open, got %d opens and %v", opens, err)
+		}
+	})
+
+	t.Run("gives up without progress", func(t *testing.T) {
+		opens := 0
+		err := ResumeStream(context.Background(),
+			func(_ context.Context, _ *string) (*fakeStream, error) {
+				opens++
+				return &fakeStream{messages: messages, dropAfter: 0, err: unavailable}, nil
+			},
+			func(stream *fakeStream) (string, error) {
+				return stream.recv()
+			},
+			Initial(time.Millisecond), Tries(3),
+		)
+
+		if !errors.Is(err, unavailable) || opens != 3 {
+			t.Errorf("expected unavailable after 3 opens, got %d opens and %v", opens, err)
+		}
+	})
+}
+
+// fakeConn is a connection that drops when its done channel is closed.
+type fakeConn struct {
+	done   chan struct{}
+	closed atomic.Int32
+}
+
+func (c *fakeConn) Done() <-chan struct{} { return c.done }
+
+func (c *fakeConn) Close() error {
+	c.closed.Add(1)
+	return nil
+}
+
+func TestReconnect(t *testing.T) {
+	t.Run("redials with a fresh schedule after a drop", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		conns := make(chan *fakeConn)
+		dials := 0
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- Reconnect(ctx, func(context.Context) (io.Closer, error) {
+				// Every connection takes three dials, using up Tries(3)
+				if dials++; dials%3 != 0 {
+					return nil, errors.New("connection refused")
+				}
+				conn := &fakeConn{done: make(chan struct{})}
+				conns <- conn
+				return conn, nil
+			}, Initial(time.Millisecond), Tries(3))
+		}()
+
+		first := <-conns
+		close(first.done)
+		second := <-conns
+		cancel()
+
+		if err := <-errCh; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if dials != 6 {
+			t.Errorf("expected 6 dials, got %d", dials)
+		}
+		if first.closed.Load() != 1 || second.closed.Load() != 1 {
+			t.Errorf("expected both connections closed once, got %d and %d", first.closed.Load(), second.closed.Load())
+		}
+	})
+
+	t.Run("gives up when dialing fails", func(t *testing.T) {
+		refused := errors.New("connection refused")
+		dials := 0
+		err := Reconnect(context.Background(), func(context.Context) (io.Closer, error) {
+			dials++
+			return nil, refused
+		}, Initial(time.Millisecond), Tries(3))
+
+		if !errors.Is(err, refused) || dials != 3 {
+			t.Errorf("expected the dial error after 3 dials, got %v after %d", err, dials)
+		}
+	})
+
+	t.Run("nil connection without an error", func(t *testing.T) {
+		dials := 0
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- Reconnect(context.Background(), func(context.Context) (io.Closer, error) {
+				dials++
+				return nil, nil
+			}, Initial(time.Millisecond), Tries(3))
+		}()
+
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, errNilConn) || dials != 1 {
+				t.Errorf("expected errNilConn after 1 dial, got %v after %d", err, dials)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Reconnect blocked on a nil connection")
+		}
+	})
+
+	t.Run("ErrStop ends without a connection", func(t *testing.T) {
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- Reconnect(context.Background(), func(context.Context) (io.Closer, error) {
+				return nil, ErrStop
+			}, Initial(time.Millisecond), Tries(3))
+		}()
+
+		select {
+		case err := <-errCh:
+			if err != nil {
+				t.Errorf("expected nil, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Reconnect blocked after ErrStop")
+		}
+	})
+}
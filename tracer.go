@@ -0,0 +1,25 @@
+package ebo
+
+import "context"
+
+// attemptSpanName is the name of the span created around each attempt
+const attemptSpanName = "retry.attempt"
+
+// Tracer starts spans around retry attempts. It is deliberately minimal so
+// that ebo does not depend on any tracing library; adapting OpenTelemetry or
+// another tracer takes a few lines.
+//
+// The context given to StartSpan carries the attempt's RetryState, so the
+// attempt number can be read with FromContext and recorded on the span.
+// The returned function ends the span with the attempt's error (nil on success).
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, func(err error))
+}
+
+// startSpan starts an attempt span on ctx if a tracer is configured.
+func (c *RetryConfig) startSpan(ctx context.Context) (context.Context, func(error)) {
+	if c.tracer == nil {
+		return ctx, func(error) {}
+	}
+	return c.tracer.StartSpan(ctx, attemptSpanName)
+}
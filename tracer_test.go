@@ -0,0 +1,96 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeSpan struct {
+	name    string
+	attempt int
+	err     error
+	ended   bool
+}
+
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+type fakeSpanKey struct{}
+
+func (t *fakeTracer) StartSpan(ctx context.Context, name string) (context.Context, func(err error)) {
+	span := &fakeSpan{name: name}
+	if state, ok := FromContext(ctx); ok {
+		span.attempt = state.Attempt
+	}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, fakeSpanKey{}, span), func(err error) {
+		span.err = err
+		span.ended = true
+	}
+}
+
+func TestWithTracer(t *testing.T) {
+	failure := errors.New("temporary")
+
+	check := func(t *testing.T, tracer *fakeTracer) {
+		t.Helper()
+		if len(tracer.spans) != 3 {
+			t.Fatalf("expected 3 spans, got %d", len(tracer.spans))
+		}
+		for i, span := range tracer.spans {
+			if span.name != "retry.attempt" {
+				t.Errorf("span %d: expected name retry.attempt, got %q", i, span.name)
+			}
+			if span.attempt != i+1 {
+				t.Errorf("span %d: expected attempt %d, got %d", i, i+1, span.attempt)
+			}
+			if !span.ended {
+				t.Errorf("span %d was not ended", i)
+			}
+		}
+		if !errors.Is(tracer.spans[0].err, failure) || !errors.Is(tracer.spans[1].err, failure) {
+			t.Errorf("expected failed spans to record the error")
+		}
+		if tracer.spans[2].err != nil {
+			t.Errorf("expected last span to succeed, got %v", tracer.spans[2].err)
+		}
+	}
+
+	t.Run("Retry", func(t *testing.T) {
+		tracer := &fakeTracer{}
+		attempts := 0
+		err := Retry(func() error {
+			attempts++
+			if attempts < 3 {
+				return failure
+			}
+			return nil
+		}, Tries(5), Initial(time.Millisecond), WithContext(context.Background()), WithTracer(tracer))
+
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		check(t, tracer)
+	})
+
+	t.Run("DoWithAttemptsContext", func(t *testing.T) {
+		tracer := &fakeTracer{}
+		err := DoWithAttemptsContext(context.Background(), func(attempt *Attempt) error {
+			if attempt.Context.Value(fakeSpanKey{}) == nil {
+				t.Errorf("attempt %d: span missing from attempt context", attempt.Number)
+			}
+			if attempt.Number < 3 {
+				return failure
+			}
+			return nil
+		}, Tries(5), Initial(time.Millisecond), WithTracer(tracer))
+
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		check(t, tracer)
+	})
+}
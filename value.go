@@ -0,0 +1,248 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// RetryValue is Retry for functions that return a value. The value from the
+// successful call is returned. With WithValidResult, a result that is not
+// valid is retried even though fn returned no error; if the attempts run out
+// on such a result, the last value is returned with an error wrapping
+// ErrRetriesExhausted, ErrNotDone and ErrMaxAttempts or ErrMaxElapsed.
+// It panics if WithValidResult was given for another type than T.
+//
+// Example:
+//
+//	orders, err := ebo.RetryValue(func() ([]Order, error) {
+//	    return store.ListOrders(customerID)
+//	}, ebo.WithValidResult(func(orders []Order) bool {
+//	    return len(orders) > 0 // The replica may not have caught up yet
+//	}), ebo.Tries(5))
+func RetryValue[T any](fn func() (T, error), opts ...Option) (T, error) {
+	config := newConfig(opts...)
+	return retryValue(config, validator[T](config), fn)
+}
+
+// validator returns the WithValidResult function of config, if any, panicking
+// if it was given for another type than T.
+func validator[T any](config *RetryConfig) func(T) bool {
+	valid, ok := config.validResult.(func(T) bool)
+	if !ok && config.validResult != nil {
+		panic(fmt.Sprintf("ebo: WithValidResult takes a %T, but the retried function returns a %v", config.validResult, reflect.TypeFor[T]()))
+	}
+	return valid
+}
+
+// retryValue runs the retry loop of RetryValue, retrying the values valid
+// rejects when it is not nil.
+func retryValue[T any](config *RetryConfig, valid func(T) bool, fn func() (T, error)) (T, error) {
+	var value T
+	reason, err := retryWithReason(config, func() error {
+		v, err := fn()
+		if err != nil && !errors.Is(err, ErrStop) {
+			return err
+		}
+		value = v
+		if err == nil && valid != nil && !valid(v) {
+			return ErrNotDone
+		}
+		return err
+	})
+	if errors.Is(err, ErrNotDone) {
+		err = fmt.Errorf("%w: %w", ErrRetriesExhausted, stopError(reason, err))
+	}
+
+	return value, err
+}
+
+// RetryWithFallback retries primary as in RetryValue and, if it does not
+// succeed, whether the attempts ran out or the error was permanent, returns
+// the result of fallback called with the final error. fallback is called
+// once and not retried; it typically serves stale data or a default value.
+//
+// Example:
+//
+//	price, err := ebo.RetryWithFallback(func() (Price, error) {
+//	    return pricing.Get(sku)
+//	}, func(err error) (Price, error) {
+//	    log.Printf("pricing unavailable, serving cached price: %v", err)
+//	    return cache.Price(sku)
+//	}, ebo.API())
+func RetryWithFallback[T any](primary func() (T, error), fallback func(err error) (T, error), opts ...Option) (T, error) {
+	value, err := RetryValue(primary, opts...)
+	if err != nil {
+		return fallback(err)
+	}
+	return value, nil
+}
+
+// WithValidResult makes RetryValue and RetryValueAsync retry results for
+// which valid returns false, such as an empty list from an eventually
+// consistent store. T must match the value type of the retried function;
+// they panic otherwise.
+//
+// Example:
+//
+//	items, err := ebo.RetryValue(fetchItems, ebo.WithValidResult(func(items []Item) bool {
+//	    return len(items) > 0
+//	}))
+func WithValidResult[T any](valid func(T) bool) Option {
+	return func(c *RetryConfig) {
+		c.validResult = valid
+	}
+}
+
+// ErrNotDone is retried by RetryValue while its result is not valid, and by
+// RetryValueUntil and RetryPoll while it is not ready yet.
+var ErrNotDone = errors.New("ebo: value not done")
+
+// ErrRetriesExhausted is returned, along with the last value, when the
+// attempts of RetryValue, RetryValueUntil or RetryPoll run out on a value that
+// is not valid, not done or not ready, rather than on an error from fn.
+var ErrRetriesExhausted = errors.New("ebo: retries exhausted without a valid value")
+
+// RetryValueUntil calls fn until it returns a value satisfying done and
+// returns that value. It is RetryValue with done given as WithValidResult,
+// replacing any other. Errors from fn are handled as in Retry: they are
+// retried unless permanent, and ErrStop ends retrying with the value fn
+// returned. When the attempts run out, the last value is returned with the
+// last error, which wraps ErrRetriesExhausted if fn succeeded but done was
+// false.
+//
+// Example:
+//
+//	job, err := ebo.RetryValueUntil(func() (*Job, error) {
+//	    return client.GetJob(id)
+//	}, func(j *Job) bool {
+//	    return j.State == "succeeded" || j.State == "failed"
+//	}, ebo.Initial(time.Second), ebo.MaxTime(10*time.Minute))
+func RetryValueUntil[T any](fn func() (T, error), done func(T) bool, opts ...Option) (T, error) {
+	return RetryValue(fn, append(opts[:len(opts):len(opts)], WithValidResult(done))...)
+}
+
+// polled is a value returned by the function given to RetryPoll.
+type polled[T any] struct {
+	value T
+	ready bool
+}
+
+// RetryPoll calls fn until it reports ready and returns the value it produced.
+// ready == false is retried as "not yet" without fn having to invent an error;
+// a non-nil error is handled as in Retry. When the attempts run out, the last
+// value is returned with the last error, which wraps ErrRetriesExhausted if fn
+// never reported ready.
+//
+// Example:
+//
+//	report, err := ebo.RetryPoll(func() (*Report, bool, error) {
+//	    r, err := api.GetReport(id)
+//	    if err != nil {
+//	        return nil, false, err
+//	    }
+//	    return r, r.Status == "complete", nil
+//	}, ebo.Initial(2*time.Second), ebo.MaxTime(5*time.Minute))
+func RetryPoll[T any](fn func() (T, bool, error), opts ...Option) (T, error) {
+	p, err := RetryValueUntil(func() (polled[T], error) {
+		v, ready, err := fn()
+		return polled[T]{v, ready}, err
+	}, func(p polled[T]) bool {
+		return p.ready
+	}, opts...)
+
+	return p.value, err
+}
+
+// RetryProgress runs an operation that advances in steps, such as a
+// resumable upload, without losing progress to failures. step is called with
+// the current state and returns the next one, whether the operation is done,
+// and an error. While step succeeds it is called again right away with the
+// state it returned; when it fails, the loop backs off as in Retry and calls
+// step again with the last state it returned without an error, so a retry
+// resumes where the operation left off instead of starting over from initial.
+// Once a step has made progress, a failure starts a fresh schedule, with
+// Tries and MaxTime of its own, after the first backoff. They thus bound the
+// retries without progress rather than the whole operation, so a long upload
+// is not given up on because of failures spread over time.
+//
+// The last good state is returned along with the error once retrying gives
+// up, so the caller can resume later. ctx is passed to step and interrupts
+// the backoff sleeps.
+//
+// Example:
+//
+//	offset, err := ebo.RetryProgress(ctx, int64(0), func(ctx context.Context, offset int64) (int64, bool, error) {
+//	    n, err := upload.SendChunk(ctx, file, offset)
+//	    if err != nil {
+//	        return offset, false, err
+//	    }
+//	    return offset + n, offset+n == size, nil
+//	}, ebo.Tries(10), ebo.Max(30*time.Second))
+func RetryProgress[S any](ctx context.Context, initial S, step func(ctx context.Context, state S) (S, bool, error), opts ...Option) (S, error) {
+	config := newConfig(append(opts[:len(opts):len(opts)], WithContext(ctx))...)
+
+	state := initial
+	for {
+		var failed error
+		err := retry(config, func() error {
+			for progressed := false; ; progressed = true {
+				next, done, err := step(ctx, state)
+				switch {
+				case err != nil && !errors.Is(err, ErrStop) && progressed:
+					// Leave this schedule to retry with a fresh one
+					failed = err
+					return nil
+				case err != nil && !errors.Is(err, ErrStop):
+					return err
+				}
+				state = next
+				if done || err != nil {
+					return err
+				}
+			}
+		})
+		if err != nil || failed == nil {
+			return state, err
+		}
+
+		if err, stop := config.permanent(failed); stop {
+			return state, err
+		}
+		if delay := config.delay(2); delay > 0 {
+			if err := config.wait(ctx, delay); err != nil {
+				return state, err
+			}
+		}
+	}
+}
+
+// Wrap returns a version of fn that retries with opts on every call, so a
+// retrying operation can be built once and then called like the original.
+// Each call is retried as in RetryValue, with its own backoff schedule.
+//
+// Example:
+//
+//	fetchConfig := ebo.Wrap(client.FetchConfig, ebo.API())
+//	cfg, err := fetchConfig()
+func Wrap[T any](fn func() (T, error), opts ...Option) func() (T, error) {
+	return func() (T, error) {
+		return RetryValue(fn, opts...)
+	}
+}
+
+// Wrap1 is Wrap for functions taking one argument, which is passed unchanged
+// to every attempt.
+//
+// Example:
+//
+//	getUser := ebo.Wrap1(client.GetUser, ebo.Tries(3))
+//	user, err := getUser(id)
+func Wrap1[A, T any](fn func(A) (T, error), opts ...Option) func(A) (T, error) {
+	return func(a A) (T, error) {
+		return RetryValue(func() (T, error) {
+			return fn(a)
+		}, opts...)
+	}
+}
@@ -0,0 +1,421 @@
+package ebo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryValueUntil(t *testing.T) {
+	t.Run("polls until threshold", func(t *testing.T) {
+		counter := 0
+		v, err := RetryValueUntil(func() (int, error) {
+			counter++
+			return counter, nil
+		}, func(n int) bool { return n >= 4 }, Initial(time.Millisecond), Tries(10))
+
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if v != 4 {
+			t.Errorf("expected 4, got %d", v)
+		}
+	})
+
+	t.Run("errors are retried", func(t *testing.T) {
+		counter := 0
+		v, err := RetryValueUntil(func() (int, error) {
+			counter++
+			if counter%2 == 1 {
+				return 0, errors.New("flaky")
+			}
+			return counter, nil
+		}, func(n int) bool { return n >= 4 }, Initial(time.Millisecond), Tries(10))
+
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if v != 4 {
+			t.Errorf("expected 4, got %d", v)
+		}
+	})
+
+	t.Run("exhausted returns last value", func(t *testing.T) {
+		counter := 0
+		v, err := RetryValueUntil(func() (int, error) {
+			counter++
+			return counter, nil
+		}, func(n int) bool { return n >= 100 }, Initial(time.Millisecond), Tries(3))
+
+		if !errors.Is(err, ErrRetriesExhausted) || !errors.Is(err, ErrNotDone) {
+			t.Errorf("expected ErrRetriesExhausted, got %v", err)
+		}
+		if v != 3 {
+			t.Errorf("expected last value 3, got %d", v)
+		}
+	})
+
+	t.Run("permanent error stops", func(t *testing.T) {
+		calls := 0
+		fatal := errors.New("job not found")
+		_, err := RetryValueUntil(func() (int, error) {
+			calls++
+			return 0, &permanentError{fatal}
+		}, func(int) bool { return true }, Initial(time.Millisecond), Tries(5))
+
+		if !errors.Is(err, fatal) {
+			t.Errorf("expected permanent error, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+}
+
+func TestRetryPoll(t *testing.T) {
+	t.Run("ready on third call", func(t *testing.T) {
+		calls := 0
+		v, err := RetryPoll(func() (string, bool, error) {
+			calls++
+			if calls < 3 {
+				return "pending", false, nil
+			}
+			return "complete", true, nil
+		}, Initial(time.Millisecond), Tries(5))
+
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if v != "complete" {
+			t.Errorf("expected 'complete', got %q", v)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("never ready", func(t *testing.T) {
+		v, err := RetryPoll(func() (string, bool, error) {
+			return "pending", false, nil
+		}, Initial(time.Millisecond), Tries(3))
+
+		if !errors.Is(err, ErrRetriesExhausted) || !errors.Is(err, ErrNotDone) {
+			t.Errorf("expected ErrRetriesExhausted, got %v", err)
+		}
+		if v != "pending" {
+			t.Errorf("expected last value 'pending', got %q", v)
+		}
+	})
+
+	t.Run("permanent error stops", func(t *testing.T) {
+		calls := 0
+		fatal := errors.New("report deleted")
+		_, err := RetryPoll(func() (string, bool, error) {
+			calls++
+			return "", false, &permanentError{fatal}
+		}, Initial(time.Millisecond), Tries(5))
+
+		if !errors.Is(err, fatal) {
+			t.Errorf("expected permanent error, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+}
+
+func TestRetryProgress(t *testing.T) {
+	t.Run("resumes from the last good state", func(t *testing.T) {
+		var seen []int
+		failed := map[int]bool{}
+		state, err := RetryProgress(context.Background(), 0, func(_ context.Context, state int) (int, bool, error) {
+			seen = append(seen, state)
+			// Fail once at 2 and once at 4, reporting a bogus state
+			if (state == 2 || state == 4) && !failed[state] {
+				failed[state] = true
+				return -1, false, errors.New("connection reset")
+			}
+			return state + 1, state+1 == 5, nil
+		}, Initial(time.Millisecond), Tries(3))
+
+		if err != nil || state != 5 {
+			t.Fatalf("expected state 5, got %d and %v", state, err)
+		}
+		if want := []int{0, 1, 2, 2, 3, 4, 4}; !slices.Equal(seen, want) {
+			t.Errorf("expected steps from %v, got %v", want, seen)
+		}
+	})
+
+	t.Run("progress starts a fresh schedule", func(t *testing.T) {
+		failed := false
+		state, err := RetryProgress(context.Background(), 0, func(_ context.Context, state int) (int, bool, error) {
+			if state == 3 && !failed {
+				failed = true
+				return 0, false, errors.New("blip")
+			}
+			// Every step alone takes most of MaxTime
+			time.Sleep(60 * time.Millisecond)
+			return state + 1, state+1 == 5, nil
+		}, Initial(time.Millisecond), MaxTime(100*time.Millisecond))
+
+		if err != nil || state != 5 {
+			t.Errorf("expected state 5 after retrying the blip, got %d and %v", state, err)
+		}
+	})
+
+	t.Run("failures without progress use up Tries", func(t *testing.T) {
+		steps := 0
+		failure := errors.New("connection reset")
+		state, err := RetryProgress(context.Background(), 0, func(_ context.Context, state int) (int, bool, error) {
+			if steps++; state == 2 {
+				return 0, false, failure
+			}
+			return state + 1, false, nil
+		}, Initial(time.Millisecond), Tries(3))
+
+		// Two good steps and a failure, then a fresh schedule of 3 failed tries
+		if !errors.Is(err, failure) || state != 2 || steps != 6 {
+			t.Errorf("expected the failure at state 2 after 6 steps, got %d after %d and %v", state, steps, err)
+		}
+	})
+
+	t.Run("gives up with the last good state", func(t *testing.T) {
+		failure := errors.New("connection reset")
+		state, err := RetryProgress(context.Background(), 0, func(_ context.Context, state int) (int, bool, error) {
+			if state == 3 {
+				return 0, false, failure
+			}
+			return state + 1, false, nil
+		}, Initial(time.Millisecond), Tries(3))
+
+		if !errors.Is(err, failure) || state != 3 {
+			t.Errorf("expected the failure at state 3, got %d and %v", state, err)
+		}
+	})
+
+	t.Run("context cancellation stops", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		_, err := RetryProgress(ctx, 0, func(context.Context, int) (int, bool, error) {
+			return 0, false, errors.New("connection reset")
+		}, Initial(time.Second), Tries(3))
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestRetryValue(t *testing.T) {
+	nonEmpty := WithValidResult(func(items []string) bool { return len(items) > 0 })
+
+	t.Run("retries empty slice until non-empty", func(t *testing.T) {
+		calls := 0
+		items, err := RetryValue(func() ([]string, error) {
+			calls++
+			if calls < 3 {
+				return nil, nil
+			}
+			return []string{"a", "b"}, nil
+		}, nonEmpty, Initial(time.Millisecond), Tries(5))
+
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if len(items) != 2 {
+			t.Errorf("expected 2 items, got %v", items)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("exhausted returns last invalid value", func(t *testing.T) {
+		items, err := RetryValue(func() ([]string, error) {
+			return []string{}, nil
+		}, nonEmpty, Initial(time.Millisecond), Tries(3))
+
+		if !errors.Is(err, ErrRetriesExhausted) || !errors.Is(err, ErrNotDone) || !errors.Is(err, ErrMaxAttempts) {
+			t.Errorf("expected ErrRetriesExhausted, ErrNotDone and ErrMaxAttempts, got %v", err)
+		}
+		if items == nil || len(items) != 0 {
+			t.Errorf("expected the last (empty) value, got %#v", items)
+		}
+	})
+
+	t.Run("errors are not exhaustion", func(t *testing.T) {
+		failed := errors.New("store unavailable")
+		_, err := RetryValue(func() ([]string, error) {
+			return nil, failed
+		}, nonEmpty, Initial(time.Millisecond), Tries(3))
+
+		if !errors.Is(err, failed) || errors.Is(err, ErrRetriesExhausted) {
+			t.Errorf("expected the error from fn without ErrRetriesExhausted, got %v", err)
+		}
+	})
+
+	t.Run("without validator", func(t *testing.T) {
+		v, err := RetryValue(func() (int, error) { return 0, nil }, Tries(3))
+		if err != nil || v != 0 {
+			t.Errorf("expected (0, nil), got (%d, %v)", v, err)
+		}
+	})
+
+	t.Run("mismatched type panics", func(t *testing.T) {
+		defer func() {
+			if p := recover(); p == nil || !strings.Contains(fmt.Sprint(p), "retried function returns a int") {
+				t.Errorf("expected a type mismatch panic, got %v", p)
+			}
+		}()
+		_, _ = RetryValue(func() (int, error) { return 0, nil }, nonEmpty, Tries(3))
+	})
+
+	t.Run("async panics in the caller on a mismatched type", func(t *testing.T) {
+		defer func() {
+			if p := recover(); p == nil {
+				t.Error("expected a type mismatch panic")
+			}
+		}()
+		RetryValueAsync(func() (int, error) { return 0, nil }, nonEmpty, Tries(3))
+	})
+
+	t.Run("async honors validator", func(t *testing.T) {
+		calls := 0
+		res := <-RetryValueAsync(func() ([]string, error) {
+			calls++
+			if calls < 2 {
+				return nil, nil
+			}
+			return []string{"a"}, nil
+		}, nonEmpty, Initial(time.Millisecond), Tries(5))
+
+		if res.Err != nil || len(res.Value) != 1 {
+			t.Errorf("expected one item, got %v, %v", res.Value, res.Err)
+		}
+	})
+}
+
+func TestWrap(t *testing.T) {
+	t.Run("retries transparently", func(t *testing.T) {
+		calls := 0
+		fetch := Wrap(func() (string, error) {
+			calls++
+			if calls%2 == 1 {
+				return "", errors.New("temporary error")
+			}
+			return "config", nil
+		}, Initial(time.Millisecond), Tries(3))
+
+		for i := range 2 {
+			v, err := fetch()
+			if err != nil || v != "config" {
+				t.Errorf("call %d: expected (config, nil), got (%q, %v)", i+1, v, err)
+			}
+		}
+		if calls != 4 {
+			t.Errorf("expected 4 calls for 2 wrapped calls, got %d", calls)
+		}
+	})
+
+	t.Run("returns last error", func(t *testing.T) {
+		failure := errors.New("failure")
+		fetch := Wrap(func() (int, error) { return 0, failure }, Initial(time.Millisecond), Tries(2))
+		if _, err := fetch(); !errors.Is(err, failure) {
+			t.Errorf("expected %v, got %v", failure, err)
+		}
+	})
+}
+
+func TestWrap1(t *testing.T) {
+	var seen []int
+	square := Wrap1(func(n int) (int, error) {
+		seen = append(seen, n)
+		if len(seen) < 3 {
+			return 0, errors.New("temporary error")
+		}
+		return n * n, nil
+	}, Initial(time.Millisecond), Tries(5))
+
+	v, err := square(7)
+	if err != nil || v != 49 {
+		t.Fatalf("expected (49, nil), got (%d, %v)", v, err)
+	}
+	for _, n := range seen {
+		if n != 7 {
+			t.Errorf("expected every attempt to get 7, got %v", seen)
+			break
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected 3 attempts, got %d", len(seen))
+	}
+}
+
+func TestRetryWithFallback(t *testing.T) {
+	t.Run("primary succeeds", func(t *testing.T) {
+		calls, fallbacks := 0, 0
+		v, err := RetryWithFallback(func() (string, error) {
+			calls++
+			if calls < 2 {
+				return "", errors.New("temporary error")
+			}
+			return "fresh", nil
+		}, func(error) (string, error) {
+			fallbacks++
+			return "stale", nil
+		}, Initial(time.Millisecond), Tries(3))
+
+		if err != nil || v != "fresh" {
+			t.Errorf("expected (fresh, nil), got (%q, %v)", v, err)
+		}
+		if fallbacks != 0 {
+			t.Errorf("expected no fallback, got %d", fallbacks)
+		}
+	})
+
+	t.Run("primary fails, fallback succeeds", func(t *testing.T) {
+		failure := errors.New("pricing unavailable")
+		calls := 0
+		var got error
+		v, err := RetryWithFallback(func() (string, error) {
+			calls++
+			return "", failure
+		}, func(err error) (string, error) {
+			got = err
+			return "stale", nil
+		}, Initial(time.Millisecond), Tries(3))
+
+		if err != nil || v != "stale" {
+			t.Errorf("expected (stale, nil), got (%q, %v)", v, err)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 primary calls, got %d", calls)
+		}
+		if !errors.Is(got, failure) {
+			t.Errorf("expected fallback to get %v, got %v", failure, got)
+		}
+	})
+
+	t.Run("both fail", func(t *testing.T) {
+		cacheMiss := errors.New("cache miss")
+		fallbacks := 0
+		_, err := RetryWithFallback(func() (int, error) {
+			return 0, errors.New("pricing unavailable")
+		}, func(error) (int, error) {
+			fallbacks++
+			return 0, cacheMiss
+		}, Initial(time.Millisecond), Tries(3))
+
+		if !errors.Is(err, cacheMiss) {
+			t.Errorf("expected %v, got %v", cacheMiss, err)
+		}
+		if fallbacks != 1 {
+			t.Errorf("expected the fallback to run once, got %d", fallbacks)
+		}
+	})
+}